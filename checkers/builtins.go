@@ -0,0 +1,68 @@
+package checkers
+
+import (
+	"github.com/blakewilliams/manifest"
+)
+
+// Builtin is a checker that ships with manifest and can be run with
+// `manifest checker <name>`.
+type Builtin struct {
+	Name string
+	// Usage is a one line summary of what the checker enforces.
+	Usage string
+	// Doc is the long-form documentation shown by `manifest explain`.
+	Doc   string
+	Rules []manifest.Rule
+	Func  func(entry *manifest.Import, r *manifest.Result) error
+}
+
+// Command returns the command used to run the checker in the configuration.
+func (b Builtin) Command() string {
+	return "manifest checker " + b.Name
+}
+
+// Builtins are the checkers that ship with manifest.
+var Builtins = []Builtin{
+	{
+		Name:  "rails_job_perform",
+		Usage: "Ensures Rails job perform arguments are changed safely for rolling deploys",
+		Doc: `Warns when the arguments of the perform method of a Rails job are changed.
+
+During a rolling deploy, jobs enqueued by the old code can be performed by the
+new code, and the other way around. Changing the arguments of perform in a
+single deploy makes those jobs fail. Instead, add the new arguments as optional
+arguments first, deploy, then update the callers.
+
+Moved jobs and files not ending in _job.rb are ignored.`,
+		Rules: []manifest.Rule{
+			{ID: RuleArgumentsChanged, Description: "The arguments of a job's perform method were changed"},
+		},
+		Func: RailsJobArguments,
+	},
+	{
+		Name:  "pull-body",
+		Usage: "Ensures that the pull request body is not empty",
+		Doc: `Reports an error when the pull request description is empty, so reviewers
+have context on the change.
+
+Requires pull request information, either from GitHub or provided with
+--pr-json, --pr-title, and --pr-body. Nothing is reported without it. In strict
+mode the check fails when the pull request information is missing, or has
+neither a title nor a description.`,
+		Rules: []manifest.Rule{
+			{ID: RuleEmptyDescription, Description: "The pull request description is empty"},
+		},
+		Func: PullBody,
+	},
+}
+
+// Lookup returns the built-in checker with the given name.
+func Lookup(name string) (Builtin, bool) {
+	for _, builtin := range Builtins {
+		if builtin.Name == name {
+			return builtin, true
+		}
+	}
+
+	return Builtin{}, false
+}
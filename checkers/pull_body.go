@@ -6,13 +6,25 @@ import (
 	"github.com/blakewilliams/manifest"
 )
 
+// RuleEmptyDescription is reported when the pull request has no description.
+const RuleEmptyDescription = "empty-description"
+
 func PullBody(entry *manifest.Import, r *manifest.Result) error {
+	// Without pull request information, like when running locally, there's
+	// no description to check.
+	if entry.Pull == nil {
+		if entry.Strict {
+			r.Failure = "No pull request information provided"
+		}
+		return nil
+	}
+
 	if entry.Pull.Title == "" && entry.Pull.Description == "" && entry.Strict {
 		r.Failure = "No pull request description provided"
 	}
 
 	if strings.TrimSpace(entry.Pull.Description) == "" {
-		r.Error("It looks like your pull request description is empty! Please provide a description of your changes.")
+		r.ErrorRule(RuleEmptyDescription, "It looks like your pull request description is empty! Please provide a description of your changes.")
 	}
 	return nil
 }
@@ -0,0 +1,29 @@
+package checkers
+
+import (
+	"testing"
+
+	"github.com/blakewilliams/manifest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPullBody(t *testing.T) {
+	result := &manifest.Result{Comments: make([]manifest.Comment, 0)}
+	require.NoError(t, PullBody(&manifest.Import{Pull: &manifest.Pull{Title: "Add README"}}, result))
+	require.Len(t, result.Comments, 1)
+	require.Equal(t, RuleEmptyDescription, result.Comments[0].Rule)
+
+	result = &manifest.Result{Comments: make([]manifest.Comment, 0)}
+	require.NoError(t, PullBody(&manifest.Import{Pull: &manifest.Pull{Description: "Adds a README"}}, result))
+	require.Empty(t, result.Comments)
+}
+
+func TestPullBody_MissingPull(t *testing.T) {
+	result := &manifest.Result{Comments: make([]manifest.Comment, 0)}
+	require.NoError(t, PullBody(&manifest.Import{}, result))
+	require.Empty(t, result.Comments)
+	require.Empty(t, result.Failure)
+
+	require.NoError(t, PullBody(&manifest.Import{Strict: true}, result))
+	require.Equal(t, "No pull request information provided", result.Failure)
+}
@@ -7,19 +7,20 @@ import (
 	"github.com/blakewilliams/manifest"
 )
 
+// RuleArgumentsChanged is reported when the arguments of a job's perform
+// method are changed.
+const RuleArgumentsChanged = "perform-arguments-changed"
+
 var performRegex = regexp.MustCompile(`def\s+perform\((.*)\)`)
 
 func RailsJobArguments(entry *manifest.Import, r *manifest.Result) error {
-	for fileName, file := range entry.Diff.Files {
-		if !strings.HasSuffix(fileName, "_job.rb") {
+	for file, l := range entry.AddedLinesMatching(performRegex) {
+		// Moved jobs don't change their arguments
+		if !strings.HasSuffix(file.Name, "_job.rb") || l.Moved {
 			continue
 		}
 
-		for _, l := range file.Right {
-			if performRegex.MatchString(l.Content) {
-				r.WarnLine(fileName, "RIGHT", l.LineNo, `You have modified an ActiveRecord job's arguments. In order to avoid job failures please read and follow X documentation.`)
-			}
-		}
+		r.WarnLineRule(RuleArgumentsChanged, file.Name, "RIGHT", l.LineNo, `You have modified an ActiveRecord job's arguments. In order to avoid job failures please read and follow X documentation.`)
 	}
 
 	return nil
@@ -38,5 +38,38 @@ func TestManifest_NewFile(t *testing.T) {
 
 	require.Equal(t, "app/jobs/greeter_job.rb", comment.File)
 	require.Equal(t, uint(4), comment.Line)
+	require.Equal(t, "RIGHT", comment.Side)
 	require.Equal(t, manifest.SeverityWarn, comment.Severity)
+	require.Equal(t, RuleArgumentsChanged, comment.Rule)
+}
+
+var renamedJobDiff = `
+diff --git a/app/jobs/greeter_job.rb b/app/jobs/welcome_job.rb
+similarity index 90%
+rename from app/jobs/greeter_job.rb
+rename to app/jobs/welcome_job.rb
+index abc1234..def5678 100644
+--- a/app/jobs/greeter_job.rb
++++ b/app/jobs/welcome_job.rb
+@@ -1,4 +1,4 @@
+-class GreeterJob < ApplicationJob
+-  def perform
++class WelcomeJob < ApplicationJob
++  def perform(name)
+   end
+ end`
+
+func TestRailsJobArguments_RenamedFile(t *testing.T) {
+	diff, err := manifest.NewDiff(strings.NewReader(renamedJobDiff))
+	require.NoError(t, err)
+
+	entry := &manifest.Import{Diff: diff}
+	result := &manifest.Result{Comments: make([]manifest.Comment, 0)}
+
+	err = RailsJobArguments(entry, result)
+	require.NoError(t, err)
+
+	require.Len(t, result.Comments, 1)
+	require.Equal(t, "app/jobs/welcome_job.rb", result.Comments[0].File)
+	require.Equal(t, uint(2), result.Comments[0].Line)
 }
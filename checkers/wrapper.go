@@ -12,17 +12,9 @@ import (
 // Wrap wraps a checker function to easily handle the conversion of STDIN to
 // a `manifest.Import` and STDOUT to `manifest.Result` JSON.
 func Wrap(name string, f func(entry *manifest.Import, r *manifest.Result) error) error {
-	fi, err := os.Stdin.Stat()
-	if err != nil {
-		return fmt.Errorf("could not stat stdin: %w", err)
-	}
-	if (fi.Mode() & os.ModeCharDevice) != 0 {
-		return fmt.Errorf("stdin was not provided")
-	}
-
-	in, err := io.ReadAll(os.Stdin)
+	in, err := readImport()
 	if err != nil {
-		return fmt.Errorf("could not read error in '%s': %w", name, err)
+		return fmt.Errorf("could not read import in '%s': %w", name, err)
 	}
 
 	if len(in) == 0 {
@@ -50,3 +42,21 @@ func Wrap(name string, f func(entry *manifest.Import, r *manifest.Result) error)
 
 	return nil
 }
+
+// readImport reads the import JSON from the file manifest provides for large
+// imports, falling back to stdin.
+func readImport() ([]byte, error) {
+	if path := os.Getenv(manifest.ImportFileEnv); path != "" {
+		return os.ReadFile(path)
+	}
+
+	fi, err := os.Stdin.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("could not stat stdin: %w", err)
+	}
+	if (fi.Mode() & os.ModeCharDevice) != 0 {
+		return nil, fmt.Errorf("stdin was not provided")
+	}
+
+	return io.ReadAll(os.Stdin)
+}
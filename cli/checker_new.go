@@ -0,0 +1,238 @@
+package cli
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/blakewilliams/manifest"
+	"github.com/blakewilliams/manifest/githelpers"
+	"github.com/fatih/color"
+	"github.com/urfave/cli/v2"
+)
+
+//go:embed templates/*.tmpl
+var templates embed.FS
+
+const (
+	checkersDir = ".manifest/checkers"
+	fixturesDir = ".manifest/fixtures"
+)
+
+// checkerLanguage describes how checkers are generated for a language.
+type checkerLanguage struct {
+	template string
+	// path returns the path of the checker relative to the repository root.
+	path func(name string) string
+	// command returns the command used to run the checker.
+	command func(name string) string
+}
+
+func scriptLanguage(ext string) checkerLanguage {
+	path := func(name string) string { return filepath.ToSlash(filepath.Join(checkersDir, name+"."+ext)) }
+
+	return checkerLanguage{
+		template: "checker." + ext + ".tmpl",
+		path:     path,
+		command:  path,
+	}
+}
+
+var checkerLanguages = map[string]checkerLanguage{
+	"go": {
+		template: "checker.go.tmpl",
+		path:     func(name string) string { return filepath.ToSlash(filepath.Join(checkersDir, name, "main.go")) },
+		command:  func(name string) string { return "go run ./" + filepath.ToSlash(filepath.Join(checkersDir, name)) },
+	},
+	"ruby":   scriptLanguage("rb"),
+	"python": scriptLanguage("py"),
+	"node":   scriptLanguage("js"),
+	"sh":     scriptLanguage("sh"),
+}
+
+// manifestModule is the module Go checkers import to read the import JSON.
+const manifestModule = "github.com/blakewilliams/manifest"
+
+var checkerNameRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+const expectedTemplate = "{\n  \"comments\": []\n}\n"
+
+type CheckerNewCmd struct {
+	name       string
+	lang       string
+	configPath string
+	force      bool
+}
+
+// Run generates the checker, a fixture built from the current diff, and
+// registers the checker in the configuration file.
+func (c *CheckerNewCmd) Run(in io.Reader) error {
+	if !checkerNameRegex.MatchString(c.name) {
+		return cli.Exit("checker names can only contain lowercase letters, numbers, dashes, and underscores", ExitConfigError)
+	}
+	language, ok := checkerLanguages[c.lang]
+	if !ok {
+		return cli.Exit(fmt.Sprintf("unknown language %s, expected one of go, ruby, python, node, or sh", c.lang), ExitConfigError)
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return cli.Exit("Could not get current working directory", ExitPlatformError)
+	}
+	rootDir, err := findGitDir(cwd)
+	if err != nil {
+		return cli.Exit("manifest checker new must be run inside of a git repository", ExitConfigError)
+	}
+
+	// Go checkers are run with `go run` in the repository's module, so they
+	// can only import manifest if the module requires it.
+	if c.lang == "go" {
+		goMod, err := os.ReadFile(filepath.Join(rootDir, "go.mod"))
+		if err != nil || !requiresModule(string(goMod), manifestModule) {
+			return cli.Exit(fmt.Sprintf("--lang go requires the repository to be a Go module that requires %s, run `go get %s` first", manifestModule, manifestModule), ExitConfigError)
+		}
+	}
+
+	checkerSource, err := renderChecker(language, c.name)
+	if err != nil {
+		return cli.Exit(err, ExitPlatformError)
+	}
+
+	importJSON, err := fixtureImport(in)
+	if err != nil {
+		return cli.Exit(err, ExitInputError)
+	}
+
+	configPath := c.configPath
+	if configPath == "" {
+		configPath = filepath.Join(rootDir, "manifest.config.yaml")
+	}
+	config, err := os.ReadFile(configPath)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return cli.Exit(fmt.Sprintf("Could not read the config file: %s", err), ExitPlatformError)
+	}
+	config, err = manifest.AddCheckerToConfig(config, c.name, language.command(c.name))
+	if err != nil {
+		return cli.Exit(err, ExitConfigError)
+	}
+
+	fixtureDir := filepath.Join(fixturesDir, c.name, "default")
+	files := []struct {
+		path    string
+		content []byte
+		mode    os.FileMode
+	}{
+		{language.path(c.name), checkerSource, 0o755},
+		{filepath.Join(fixtureDir, "import.json"), importJSON, 0o644},
+		{filepath.Join(fixtureDir, "expected.json"), []byte(expectedTemplate), 0o644},
+	}
+
+	for _, file := range files {
+		if _, err := os.Stat(filepath.Join(rootDir, file.path)); err == nil && !c.force {
+			return cli.Exit(fmt.Sprintf("%s already exists, pass --force to overwrite it", file.path), ExitConfigError)
+		}
+	}
+
+	for _, file := range files {
+		fullPath := filepath.Join(rootDir, file.path)
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0o755); err != nil {
+			return cli.Exit(fmt.Sprintf("Could not create directory for %s: %s", file.path, err), ExitPlatformError)
+		}
+		if err := os.WriteFile(fullPath, file.content, file.mode); err != nil {
+			return cli.Exit(fmt.Sprintf("Could not write %s: %s", file.path, err), ExitPlatformError)
+		}
+
+		color.New(color.FgGreen).Fprintf(os.Stderr, "Created %s\n", file.path)
+	}
+
+	if err := os.WriteFile(configPath, config, 0o644); err != nil {
+		return cli.Exit(fmt.Sprintf("Could not write the config file: %s", err), ExitPlatformError)
+	}
+	color.New(color.FgGreen).Fprintf(os.Stderr, "Added %s to %s\n", c.name, filepath.Base(configPath))
+
+	return nil
+}
+
+// requiresModule returns true if the given go.mod contents declare or require
+// the given module.
+func requiresModule(goMod string, module string) bool {
+	inRequire := false
+	for _, line := range strings.Split(goMod, "\n") {
+		line, _, _ = strings.Cut(line, "//")
+		fields := strings.Fields(line)
+
+		switch {
+		case len(fields) == 0:
+		case inRequire && fields[0] == ")":
+			inRequire = false
+		case inRequire:
+			if fields[0] == module {
+				return true
+			}
+		case fields[0] == "require" && len(fields) > 1 && fields[1] == "(":
+			inRequire = true
+		case (fields[0] == "module" || fields[0] == "require") && len(fields) > 1:
+			if fields[1] == module {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func renderChecker(language checkerLanguage, name string) ([]byte, error) {
+	tmpl, err := template.ParseFS(templates, "templates/"+language.template)
+	if err != nil {
+		return nil, fmt.Errorf("could not load checker template: %w", err)
+	}
+
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, struct{ Name string }{name}); err != nil {
+		return nil, fmt.Errorf("could not render checker template: %w", err)
+	}
+
+	return out.Bytes(), nil
+}
+
+// fixtureImport returns the import JSON for the given diff, or the changes in
+// the working tree when no diff is provided, indented so it's easy to edit.
+func fixtureImport(in io.Reader) ([]byte, error) {
+	if in == nil {
+		diff, err := githelpers.WorkingTreeDiff()
+		if err != nil {
+			return nil, err
+		}
+		in = strings.NewReader(diff)
+	}
+
+	check, err := manifest.NewCheck(&manifest.Configuration{}, in)
+	if err != nil {
+		return nil, err
+	}
+	if err := annotateFromRepository(check); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: could not annotate files: %s\n", err)
+	}
+
+	out, err := check.ImportJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, out, "", "  "); err != nil {
+		return nil, err
+	}
+	indented.WriteString("\n")
+
+	return indented.Bytes(), nil
+}
@@ -0,0 +1,40 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderChecker(t *testing.T) {
+	for lang, language := range checkerLanguages {
+		t.Run(lang, func(t *testing.T) {
+			out, err := renderChecker(language, "no_todos")
+			require.NoError(t, err)
+			require.Contains(t, string(out), "no_todos is a manifest checker")
+		})
+	}
+
+	require.Equal(t, ".manifest/checkers/no_todos.rb", checkerLanguages["ruby"].command("no_todos"))
+	require.Equal(t, "go run ./.manifest/checkers/no_todos", checkerLanguages["go"].command("no_todos"))
+}
+
+func TestRequiresModule(t *testing.T) {
+	cases := []struct {
+		name  string
+		goMod string
+		want  bool
+	}{
+		{name: "require block", goMod: "module example.com/app\n\nrequire (\n\tgithub.com/blakewilliams/manifest v0.1.0 // indirect\n)\n", want: true},
+		{name: "single require", goMod: "module example.com/app\n\nrequire github.com/blakewilliams/manifest v0.1.0\n", want: true},
+		{name: "manifest itself", goMod: "module github.com/blakewilliams/manifest\n", want: true},
+		{name: "other requirements", goMod: "module example.com/app\n\nrequire (\n\tgithub.com/blakewilliams/manifest-extras v0.1.0\n)\n", want: false},
+		{name: "commented out", goMod: "module example.com/app\n\n// require github.com/blakewilliams/manifest v0.1.0\n", want: false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			require.Equal(t, c.want, requiresModule(c.goMod, manifestModule))
+		})
+	}
+}
@@ -1,11 +1,15 @@
 package cli
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
+	"os/signal"
+	"strings"
 
 	"github.com/blakewilliams/manifest/checkers"
+	"github.com/blakewilliams/manifest/github"
 	"github.com/fatih/color"
 	"github.com/urfave/cli/v2"
 )
@@ -33,6 +37,14 @@ func New() *CLI {
 						Aliases: []string{"d"},
 						Usage:   "Uses the provided diff `FILE`",
 					},
+					&cli.StringSliceFlag{
+						Name:  "patch",
+						Usage: "Uses the provided `git format-patch` or mbox `FILE` instead of a diff. Can be repeated, use - for stdin",
+					},
+					&cli.StringFlag{
+						Name:  "import",
+						Usage: "Replays the import JSON in `FILE`, as output by --json-only, instead of parsing a diff. Use - for stdin",
+					},
 					&cli.BoolFlag{
 						Name:  "json-only",
 						Usage: "Outputs only the JSON and does not run the checks",
@@ -42,22 +54,67 @@ func New() *CLI {
 						Usage: "Sets how many checks will run concurrently",
 					},
 					&cli.StringSliceFlag{
-						Name:    "checker",
-						Aliases: []string{"i"},
-						Usage:   "Runs the provided check `script`",
+						Name:  "only",
+						Usage: "Only runs the configured checkers matching `NAMES`, comma separated. Supports globs like 'rails_*'",
 					},
+					&cli.StringSliceFlag{
+						Name:  "skip",
+						Usage: "Skips the configured checkers matching `NAMES`, comma separated. Supports globs like 'rails_*'",
+					},
+					runFlag(),
 					&cli.StringFlag{
 						Name:  "formatter",
-						Usage: "Sets the formatter to use",
+						Usage: "Sets the formatter to use: pretty, annotate, or github",
 					},
 					&cli.IntFlag{
 						Name:  "pr",
 						Usage: "sets the PR to operate against",
 					},
+					&cli.StringFlag{
+						Name:  "pr-title",
+						Usage: "Sets the pull request `TITLE` passed to checkers, taking precedence over GitHub",
+					},
+					&cli.StringFlag{
+						Name:  "pr-body",
+						Usage: "Sets the pull request `BODY` passed to checkers, taking precedence over GitHub",
+					},
+					&cli.StringFlag{
+						Name:  "pr-body-file",
+						Usage: "Reads the pull request body passed to checkers from `FILE`, taking precedence over GitHub",
+					},
+					&cli.StringFlag{
+						Name:  "pr-json",
+						Usage: "Reads the pull request information passed to checkers from the JSON in `FILE`, like the output of gh pr view --json title,body,number",
+					},
 					&cli.BoolFlag{
 						Name:  "strict",
 						Usage: "fails if PR information or other optional data fails to be resolved",
 					},
+					&cli.StringFlag{
+						Name:  "base",
+						Usage: "Includes the commits in `REF`..head in the import",
+					},
+					&cli.StringFlag{
+						Name:  "head",
+						Usage: "Sets the head `REF` used alongside --base",
+						Value: "HEAD",
+					},
+					&cli.BoolFlag{
+						Name:  "per-commit",
+						Usage: "Runs the checks once for each commit's own diff. Requires --base or PR information",
+					},
+					&cli.BoolFlag{
+						Name:  "differential",
+						Usage: "Also runs checkers configured as differential against the merge base of --base, reporting only the findings introduced by the change",
+					},
+					&cli.BoolFlag{
+						Name:  "show-fixed",
+						Usage: "Reports the findings fixed by the change when using --differential",
+					},
+					&cli.BoolFlag{
+						Name:  "interactive",
+						Usage: "Triages the findings in the terminal: opens them in $EDITOR, suppresses them, or applies their suggested fix",
+					},
 					&cli.BoolFlag{
 						Name:  "no-github",
 						Usage: "Don't use the GH CLI to fetch information like the auth token",
@@ -70,73 +127,541 @@ func New() *CLI {
 					if err != nil {
 						panic(err)
 					}
-					if (fi.Mode() & os.ModeCharDevice) == 0 {
-						in = os.Stdin
-					} else if diff := cctx.String("diff"); diff != "" {
-						f, err := os.Open(diff)
-						if err != nil {
-							return err
-						}
-						defer f.Close()
-						in = f
-					} else {
-						if err := cli.ShowSubcommandHelp(cctx); err != nil {
-							fmt.Println(err)
+					// Patches and imports are read by the check command
+					if len(cctx.StringSlice("patch")) == 0 && cctx.String("import") == "" {
+						if (fi.Mode() & os.ModeCharDevice) == 0 {
+							in = os.Stdin
+						} else if diff := cctx.String("diff"); diff != "" {
+							f, err := os.Open(diff)
+							if err != nil {
+								return cli.Exit(fmt.Sprintf("Could not open the provided diff file: %s", err), ExitInputError)
+							}
+							defer f.Close()
+							in = f
+						} else {
+							if err := cli.ShowSubcommandHelp(cctx); err != nil {
+								fmt.Println(err)
+							}
+							fmt.Printf("\n")
+							return cli.Exit(color.New(color.FgRed).Sprint("No diff provided. Please provide a --diff, --patch, --import, or pass the diff via stdin."), ExitInputError)
 						}
-						fmt.Printf("\n")
-						return cli.Exit(color.New(color.FgRed).Sprint("No diff provided. Please provide a --diff or pass the diff via stdin."), 1)
 					}
 
+					return newCheckCmd(cctx).Run(in)
+				},
+			},
+			{
+				Name:      "test",
+				Usage:     "Runs the configured checkers against their fixtures and compares the results",
+				ArgsUsage: "[checkers...]",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "config",
+						Aliases: []string{"c"},
+						Usage:   "Uses provided config `FILE`",
+					},
+					&cli.StringFlag{
+						Name:  "fixtures",
+						Usage: "Uses the fixtures in `DIR` instead of .manifest/fixtures",
+					},
+					&cli.BoolFlag{
+						Name:  "update",
+						Usage: "Records the current results as the expected results",
+					},
+				},
+				Action: func(cctx *cli.Context) error {
+					checkers := cctx.Args().Slice()
+
+					testCmd := &TestCmd{
+						configPath:   cctx.String("config"),
+						fixturesPath: cctx.String("fixtures"),
+						update:       cctx.Bool("update"),
+						checkers:     checkers,
+					}
+
+					return testCmd.Run()
+				},
+			},
+			{
+				Name:  "init",
+				Usage: "Creates a manifest.config.yaml for the current repository",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "workflow",
+						Usage: "Also creates a GitHub Actions workflow that runs manifest on pull requests",
+					},
+					&cli.BoolFlag{
+						Name:  "force",
+						Usage: "Overwrites existing files",
+					},
+				},
+				Action: func(cctx *cli.Context) error {
+					initCmd := &InitCmd{
+						force:    cctx.Bool("force"),
+						workflow: cctx.Bool("workflow"),
+					}
+
+					return initCmd.Run()
+				},
+			},
+			{
+				Name:      "scan",
+				Usage:     "Runs the configured checks against every tracked file, as if each file was newly added",
+				ArgsUsage: "[paths...]",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "config",
+						Aliases: []string{"c"},
+						Usage:   "Uses provided config `FILE`",
+					},
+					&cli.BoolFlag{
+						Name:  "json-only",
+						Usage: "Outputs only the JSON and does not run the checks",
+					},
+					&cli.IntFlag{
+						Name:  "concurrency",
+						Usage: "Sets how many checks will run concurrently",
+					},
+					&cli.StringSliceFlag{
+						Name:  "only",
+						Usage: "Only runs the configured checkers matching `NAMES`, comma separated. Supports globs like 'rails_*'",
+					},
+					&cli.StringSliceFlag{
+						Name:  "skip",
+						Usage: "Skips the configured checkers matching `NAMES`, comma separated. Supports globs like 'rails_*'",
+					},
+					runFlag(),
+					&cli.StringFlag{
+						Name:  "formatter",
+						Usage: "Sets the formatter to use: pretty, annotate, or github",
+					},
+				},
+				Action: func(cctx *cli.Context) error {
+					paths := cctx.Args().Slice()
+
 					checkCmd := &CheckCmd{
-						configPath:      cctx.String("config"),
-						diffPath:        cctx.String("diff"),
-						jsonOnly:        cctx.Bool("json-only"),
-						concurrency:     cctx.Int("concurrency"),
-						formatter:       cctx.String("formatter"),
-						strict:          cctx.Bool("strict"),
-						noGH:            cctx.Bool("no-gh"),
-						cCtx:            cctx,
-						_githubPRNumber: cctx.Int("pr"),
+						configPath:  cctx.String("config"),
+						jsonOnly:    cctx.Bool("json-only"),
+						concurrency: cctx.Int("concurrency"),
+						formatter:   cctx.String("formatter"),
+						only:        cctx.StringSlice("only"),
+						skip:        cctx.StringSlice("skip"),
+						run:         runCommands(cctx),
+						noGH:        true,
+						cCtx:        cctx,
 					}
 
-					return checkCmd.Run(in)
+					return checkCmd.Scan(paths)
+				},
+			},
+			{
+				Name:  "watch",
+				Usage: "Re-runs the configured checks against the changes since --base whenever a file changes",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "config",
+						Aliases: []string{"c"},
+						Usage:   "Uses provided config `FILE`",
+					},
+					&cli.StringFlag{
+						Name:  "base",
+						Usage: "The `REF` of the base branch the changes are compared against",
+						Value: "main",
+					},
+					&cli.IntFlag{
+						Name:  "concurrency",
+						Usage: "Sets how many checks will run concurrently",
+					},
+					&cli.StringSliceFlag{
+						Name:  "only",
+						Usage: "Only runs the configured checkers matching `NAMES`, comma separated. Supports globs like 'rails_*'",
+					},
+					&cli.StringSliceFlag{
+						Name:  "skip",
+						Usage: "Skips the configured checkers matching `NAMES`, comma separated. Supports globs like 'rails_*'",
+					},
+					runFlag(),
+				},
+				Action: func(cctx *cli.Context) error {
+					checkCmd := &CheckCmd{
+						configPath:  cctx.String("config"),
+						base:        cctx.String("base"),
+						concurrency: cctx.Int("concurrency"),
+						only:        cctx.StringSlice("only"),
+						skip:        cctx.StringSlice("skip"),
+						run:         runCommands(cctx),
+						noGH:        true,
+						cCtx:        cctx,
+					}
+
+					ctx, stop := signal.NotifyContext(cctx.Context, os.Interrupt)
+					defer stop()
+
+					return checkCmd.Watch(ctx)
 				},
 			},
 			{
 				Name:  "checker",
 				Usage: "runs the given built-in checker",
-				Subcommands: []*cli.Command{
+				Subcommands: append([]*cli.Command{
 					{
-						Name:  "rails_job_perform",
-						Usage: "Runs the Rails job checker to ensure perform is modified safely for rolling deploys",
+						Name:      "new",
+						Usage:     "Generates a new checker, a fixture from the current diff, and adds it to manifest.config.yaml",
+						ArgsUsage: "<name>",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:  "lang",
+								Usage: "The `LANGUAGE` of the checker, one of go, ruby, python, node, or sh",
+								Value: "sh",
+							},
+							&cli.StringFlag{
+								Name:    "config",
+								Aliases: []string{"c"},
+								Usage:   "Adds the checker to the provided config `FILE`",
+							},
+							&cli.BoolFlag{
+								Name:  "force",
+								Usage: "Overwrites existing files",
+							},
+						},
 						Action: func(cctx *cli.Context) error {
-							err := checkers.Wrap("rails_job_perform", checkers.RailsJobArguments)
+							args := cctx.Args().Slice()
+							if len(args) != 1 {
+								return cli.Exit("Please provide the name of the checker, e.g. manifest checker new no_todos", ExitConfigError)
+							}
+
+							var in io.Reader
+							fi, err := os.Stdin.Stat()
 							if err != nil {
-								fmt.Fprintf(os.Stderr, "%s\n", err)
+								panic(err)
+							}
+							if (fi.Mode() & os.ModeCharDevice) == 0 {
+								in = os.Stdin
 							}
-							return nil
+
+							newCmd := &CheckerNewCmd{
+								name:       args[0],
+								lang:       cctx.String("lang"),
+								configPath: cctx.String("config"),
+								force:      cctx.Bool("force"),
+							}
+
+							return newCmd.Run(in)
 						},
 					},
-
+				}, builtinCommands()...),
+			},
+			{
+				Name:  "hooks",
+				Usage: "Manages the git hooks that run manifest before committing or pushing",
+				Subcommands: []*cli.Command{
 					{
-						Name:  "pull-body",
-						Usage: "Ensures that the pull request body is not empty",
+						Name:  "install",
+						Usage: "Installs the git hooks, the pre-commit hook by default",
+						Flags: []cli.Flag{
+							&cli.BoolFlag{
+								Name:  "pre-commit",
+								Usage: "Installs a pre-commit hook checking the staged changes",
+							},
+							&cli.BoolFlag{
+								Name:  "pre-push",
+								Usage: "Installs a pre-push hook checking the commits being pushed",
+							},
+							&cli.BoolFlag{
+								Name:  "force",
+								Usage: "Overwrites existing hooks that weren't installed by manifest",
+							},
+						},
 						Action: func(cctx *cli.Context) error {
-							err := checkers.Wrap("pull-body", checkers.PullBody)
-							if err != nil {
-								fmt.Fprintf(os.Stderr, "%s\n", err)
+							hooksCmd := &HooksCmd{hooks: selectedHooks(cctx, []string{preCommitHook}), force: cctx.Bool("force")}
+							return hooksCmd.Install()
+						},
+					},
+					{
+						Name:  "uninstall",
+						Usage: "Removes the git hooks installed by manifest, all of them by default",
+						Flags: []cli.Flag{
+							&cli.BoolFlag{
+								Name:  "pre-commit",
+								Usage: "Removes the pre-commit hook",
+							},
+							&cli.BoolFlag{
+								Name:  "pre-push",
+								Usage: "Removes the pre-push hook",
+							},
+						},
+						Action: func(cctx *cli.Context) error {
+							hooksCmd := &HooksCmd{hooks: selectedHooks(cctx, []string{preCommitHook, prePushHook})}
+							return hooksCmd.Uninstall()
+						},
+					},
+					{
+						Name:      "run",
+						Usage:     "Runs the checks for the given hook, used by the installed hooks",
+						ArgsUsage: "<hook> [hook arguments...]",
+						Hidden:    true,
+						Action: func(cctx *cli.Context) error {
+							if cctx.NArg() == 0 {
+								return cli.Exit("Please provide the name of the hook", ExitConfigError)
+							}
+
+							checkCmd := &CheckCmd{
+								noGH:  true,
+								local: true,
+								cCtx:  cctx,
 							}
-							return nil
+
+							return checkCmd.RunHook(cctx.Args().First(), cctx.Args().Tail(), os.Stdin)
 						},
 					},
 				},
 			},
+			{
+				Name:  "list",
+				Usage: "Lists the configured and built-in checkers",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "config",
+						Aliases: []string{"c"},
+						Usage:   "Lists the checkers in the provided config `FILE`",
+					},
+				},
+				Action: func(cctx *cli.Context) error {
+					listCmd := &ListCmd{configPath: cctx.String("config")}
+					return listCmd.Run(os.Stdout)
+				},
+			},
+			{
+				Name:      "explain",
+				Usage:     "Prints the documentation and rules of a checker",
+				ArgsUsage: "<checker>",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "config",
+						Aliases: []string{"c"},
+						Usage:   "Looks up the checker in the provided config `FILE`",
+					},
+				},
+				Action: func(cctx *cli.Context) error {
+					args := cctx.Args().Slice()
+					if len(args) != 1 {
+						return cli.Exit("Please provide the name of the checker, e.g. manifest explain pull-body", ExitConfigError)
+					}
+
+					explainCmd := &ExplainCmd{configPath: cctx.String("config"), name: args[0]}
+					return explainCmd.Run(os.Stdout)
+				},
+			},
+			{
+				Name:  "doctor",
+				Usage: "Checks that git, GitHub, and the configured checkers are set up correctly",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "config",
+						Aliases: []string{"c"},
+						Usage:   "Checks the checkers in the provided config `FILE`",
+					},
+					&cli.StringFlag{
+						Name:  "base",
+						Usage: "The `REF` of the base branch pull requests are compared against",
+						Value: "main",
+					},
+					&cli.BoolFlag{
+						Name:  "no-github",
+						Usage: "Don't use the GH CLI to fetch information like the auth token",
+					},
+				},
+				Action: func(cctx *cli.Context) error {
+					doctorCmd := &DoctorCmd{
+						configPath: cctx.String("config"),
+						base:       cctx.String("base"),
+						noGH:       cctx.Bool("no-github"),
+					}
+					return doctorCmd.Run(os.Stdout)
+				},
+			},
+			{
+				Name:  "serve",
+				Usage: "Runs a server that checks pull requests when it receives GitHub webhook deliveries",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "addr",
+						Usage: "The `ADDRESS` the server listens on",
+						Value: ":8080",
+					},
+					&cli.StringFlag{
+						Name:    "config",
+						Aliases: []string{"c"},
+						Usage:   "Uses the provided config `FILE` for every repository instead of their manifest.config.yaml",
+					},
+					&cli.IntFlag{
+						Name:  "concurrency",
+						Usage: "Sets how many pull requests are checked concurrently",
+						Value: 2,
+					},
+					&cli.IntFlag{
+						Name:  "queue-size",
+						Usage: "Sets how many pull requests can wait to be checked before deliveries are rejected",
+						Value: 100,
+					},
+					&cli.StringFlag{
+						Name:  "work-dir",
+						Usage: "The `DIR` repositories are checked out in, defaults to the temporary directory",
+					},
+					&cli.StringFlag{
+						Name:  "github-api-url",
+						Usage: "The `URL` of the GitHub API, e.g. the API of a GitHub Enterprise Server instance",
+						Value: github.DefaultBaseURL,
+					},
+				},
+				Action: func(cctx *cli.Context) error {
+					ctx, stop := signal.NotifyContext(cctx.Context, os.Interrupt)
+					defer stop()
+
+					serveCmd := &ServeCmd{
+						addr:        cctx.String("addr"),
+						secret:      []byte(os.Getenv(WebhookSecretEnv)),
+						token:       os.Getenv("MANIFEST_GITHUB_TOKEN"),
+						apiURL:      cctx.String("github-api-url"),
+						configPath:  cctx.String("config"),
+						workDir:     cctx.String("work-dir"),
+						concurrency: cctx.Int("concurrency"),
+						queueSize:   cctx.Int("queue-size"),
+						out:         os.Stderr,
+					}
+					return serveCmd.Run(ctx)
+				},
+			},
 		},
 	}
 
 	return &CLI{app: app}
 }
 
+// selectedHooks returns the hooks selected using the --pre-commit and
+// --pre-push flags, or the defaults when neither is passed.
+func selectedHooks(cctx *cli.Context, defaults []string) []string {
+	hooks := make([]string, 0, 2)
+	for _, hook := range []string{preCommitHook, prePushHook} {
+		if cctx.Bool(hook) {
+			hooks = append(hooks, hook)
+		}
+	}
+
+	if len(hooks) == 0 {
+		return defaults
+	}
+
+	return hooks
+}
+
+// builtinCommands returns a `manifest checker` subcommand for every built-in
+// checker.
+func builtinCommands() []*cli.Command {
+	commands := make([]*cli.Command, 0, len(checkers.Builtins))
+	for _, builtin := range checkers.Builtins {
+		commands = append(commands, &cli.Command{
+			Name:  builtin.Name,
+			Usage: builtin.Usage,
+			Action: func(cctx *cli.Context) error {
+				err := checkers.Wrap(builtin.Name, builtin.Func)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "%s\n", err)
+				}
+				return nil
+			},
+		})
+	}
+
+	return commands
+}
+
 func (c *CLI) Run(args []string) error {
 	return c.app.Run(args)
 }
+
+// newCheckCmd returns the check command configured by the flags of `manifest
+// check`.
+func newCheckCmd(cctx *cli.Context) *CheckCmd {
+	pull := pullOverrides{
+		jsonPath: cctx.String("pr-json"),
+		bodyPath: cctx.String("pr-body-file"),
+	}
+	if cctx.IsSet("pr-title") {
+		title := cctx.String("pr-title")
+		pull.title = &title
+	}
+	if cctx.IsSet("pr-body") {
+		body := cctx.String("pr-body")
+		pull.body = &body
+	}
+
+	return &CheckCmd{
+		configPath:      cctx.String("config"),
+		diffPath:        cctx.String("diff"),
+		importPath:      cctx.String("import"),
+		patchPaths:      cctx.StringSlice("patch"),
+		jsonOnly:        cctx.Bool("json-only"),
+		concurrency:     cctx.Int("concurrency"),
+		formatter:       cctx.String("formatter"),
+		strict:          cctx.Bool("strict"),
+		noGH:            cctx.Bool("no-github"),
+		base:            cctx.String("base"),
+		head:            cctx.String("head"),
+		only:            cctx.StringSlice("only"),
+		skip:            cctx.StringSlice("skip"),
+		run:             runCommands(cctx),
+		perCommit:       cctx.Bool("per-commit"),
+		differential:    cctx.Bool("differential"),
+		showFixed:       cctx.Bool("show-fixed"),
+		interactive:     cctx.Bool("interactive"),
+		pull:            pull,
+		cCtx:            cctx,
+		_githubPRNumber: cctx.Int("pr"),
+	}
+}
+
+// runFlag returns the --run flag. Commands can contain commas, so its values
+// aren't split like the other slice flags.
+func runFlag() *cli.GenericFlag {
+	return &cli.GenericFlag{
+		Name:    "run",
+		Aliases: []string{"checker", "i"},
+		Usage:   "Runs the provided `script` instead of the configured checkers, or alongside the ones selected with --only",
+		Value:   &commandList{},
+	}
+}
+
+// runCommands returns the commands passed to --run.
+func runCommands(cctx *cli.Context) []string {
+	if commands, ok := cctx.Generic("run").(*commandList); ok {
+		return *commands
+	}
+
+	return nil
+}
+
+// commandList is a flag value that keeps every value passed to the flag as is.
+type commandList []string
+
+// serializedCommandsPrefix marks a serialized commandList, which urfave/cli
+// sets on the flag's aliases after parsing.
+const serializedCommandsPrefix = "commands:::"
+
+func (l *commandList) Set(value string) error {
+	if serialized, ok := strings.CutPrefix(value, serializedCommandsPrefix); ok {
+		return json.Unmarshal([]byte(serialized), l)
+	}
+
+	*l = append(*l, value)
+	return nil
+}
+
+func (l *commandList) String() string {
+	return strings.Join(*l, ", ")
+}
+
+func (l *commandList) Serialize() string {
+	serialized, _ := json.Marshal(*l)
+	return serializedCommandsPrefix + string(serialized)
+}
@@ -0,0 +1,54 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v2"
+)
+
+// parseCommand parses the arguments of the given top level command without
+// running it, returning the parsed context.
+func parseCommand(t *testing.T, command string, args ...string) *cli.Context {
+	t.Helper()
+
+	var parsed *cli.Context
+	app := New()
+	for _, cmd := range app.app.Commands {
+		if cmd.Name == command {
+			cmd.Action = func(cctx *cli.Context) error {
+				parsed = cctx
+				return nil
+			}
+		}
+	}
+
+	require.NoError(t, app.Run(append([]string{"manifest", command}, args...)))
+	require.NotNil(t, parsed, "%s wasn't run", command)
+
+	return parsed
+}
+
+func TestRunFlag(t *testing.T) {
+	cctx := parseCommand(t, "check", "--run", `jq -c '{comments: [.a, .b]}'`, "--run", "script/lint", "--only", "a,b")
+
+	require.Equal(t, []string{`jq -c '{comments: [.a, .b]}'`, "script/lint"}, runCommands(cctx))
+	require.Equal(t, []string{"a", "b"}, cctx.StringSlice("only"))
+}
+
+func TestRunFlag_Alias(t *testing.T) {
+	cctx := parseCommand(t, "scan", "-i", "script/lint --rules a,b")
+
+	require.Equal(t, []string{"script/lint --rules a,b"}, runCommands(cctx))
+}
+
+func TestNewCheckCmd(t *testing.T) {
+	cmd := newCheckCmd(parseCommand(t, "check", "--no-github", "--strict", "--pr", "12", "--pr-title", "Fix"))
+
+	require.True(t, cmd.noGH)
+	require.True(t, cmd.strict)
+	require.Equal(t, 12, cmd._githubPRNumber)
+	require.Equal(t, "Fix", *cmd.pull.title)
+
+	require.False(t, newCheckCmd(parseCommand(t, "check")).noGH)
+}
@@ -0,0 +1,280 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/blakewilliams/manifest"
+	"github.com/blakewilliams/manifest/githelpers"
+	"github.com/fatih/color"
+	"github.com/urfave/cli/v2"
+)
+
+// diagnosis is the result of a single `manifest doctor` check.
+type diagnosis struct {
+	name string
+	err  error
+	// hint explains how to fix the problem when err is set.
+	hint string
+	// configuration is true if the check is about manifest.config.yaml rather
+	// than the environment.
+	configuration bool
+}
+
+type DoctorCmd struct {
+	configPath string
+	base       string
+	noGH       bool
+}
+
+// Run checks the prerequisites of manifest and the configured checkers,
+// printing how to fix each problem found.
+func (c *DoctorCmd) Run(out io.Writer) error {
+	diagnoses := c.diagnose()
+
+	failed := writeDiagnoses(out, diagnoses)
+	if failed > 0 {
+		return cli.Exit(color.New(color.FgRed).Sprintf("%d of %d checks failed", failed, len(diagnoses)), diagnosesExitCode(diagnoses))
+	}
+
+	color.New(color.FgGreen).Fprintln(out, "\nEverything looks good!")
+	return nil
+}
+
+func (c *DoctorCmd) diagnose() []diagnosis {
+	diagnoses := make([]diagnosis, 0)
+	add := func(name string, err error, hint string) bool {
+		diagnoses = append(diagnoses, diagnosis{name: name, err: err, hint: hint})
+		return err == nil
+	}
+
+	// The git helpers can't be used without git
+	_, err := exec.LookPath("git")
+	if !add("git is installed", err, "Install git and make sure it's on your PATH") {
+		return diagnoses
+	}
+
+	cwd, err := os.Getwd()
+	if err == nil {
+		_, err = findGitDir(cwd)
+	}
+	if !add("inside a git repository", err, "Run manifest from inside the repository you want to check") {
+		return diagnoses
+	}
+
+	config := &manifest.Configuration{Checkers: map[string]manifest.Checker{}}
+	configErr := applyConfig(c.configPath, config)
+	diagnoses = append(diagnoses, diagnosis{
+		name:          "configuration is valid",
+		err:           configErr,
+		hint:          "Fix manifest.config.yaml, see the Usage section of the README",
+		configuration: true,
+	})
+
+	diagnoses = append(diagnoses, c.diagnoseGitHub()...)
+
+	shallow, err := githelpers.IsShallow()
+	if err == nil && shallow {
+		err = errors.New("the repository is a shallow clone")
+	}
+	add("history is complete", err, "Fetch the full history with `git fetch --unshallow`, or use `fetch-depth: 0` with actions/checkout")
+
+	_, err = githelpers.MergeBase(c.base, "HEAD")
+	add(fmt.Sprintf("merge base with %s exists", c.base), err, fmt.Sprintf("Fetch the base branch, e.g. `git fetch origin %s`, or pass the base branch with --base", c.base))
+
+	if configErr == nil {
+		diagnoses = append(diagnoses, diagnoseCheckers(config)...)
+	}
+
+	return diagnoses
+}
+
+// diagnosesExitCode returns the exit code for the failed diagnoses. Problems
+// that are only in the configuration use ExitConfigError, like `manifest
+// check` does.
+func diagnosesExitCode(diagnoses []diagnosis) int {
+	for _, d := range diagnoses {
+		if d.err != nil && !d.configuration {
+			return ExitPlatformError
+		}
+	}
+
+	return ExitConfigError
+}
+
+// diagnoseGitHub checks the GitHub remote, the token, and that the API can be
+// reached using the same client as `manifest check`.
+func (c *DoctorCmd) diagnoseGitHub() []diagnosis {
+	diagnoses := make([]diagnosis, 0)
+	add := func(name string, err error, hint string) bool {
+		diagnoses = append(diagnoses, diagnosis{name: name, err: err, hint: hint})
+		return err == nil
+	}
+
+	owner, repo, err := githelpers.NwoFromOrigin()
+	if !add("origin is a GitHub repository", err, "Add the GitHub repository as the origin remote, e.g. `git remote add origin git@github.com:owner/repo.git`") {
+		return diagnoses
+	}
+
+	if os.Getenv("MANIFEST_GITHUB_TOKEN") == "" {
+		if c.noGH {
+			add("GitHub token is available", errNoGitHubToken, "Set MANIFEST_GITHUB_TOKEN, or remove --no-github to use the token of the gh CLI")
+			return diagnoses
+		}
+
+		_, err := exec.LookPath("gh")
+		if !add("gh is installed", err, "Install the GitHub CLI from https://cli.github.com, or set MANIFEST_GITHUB_TOKEN") {
+			return diagnoses
+		}
+	}
+
+	checkCmd := &CheckCmd{noGH: c.noGH}
+	client, err := checkCmd.GitHubClient()
+	if !add("GitHub token is available", err, "Run `gh auth login`, or set MANIFEST_GITHUB_TOKEN") {
+		return diagnoses
+	}
+
+	branch, err := githelpers.CurrentBranch()
+	if err == nil {
+		_, err = client.PullRequestIDsForBranch(branch)
+	}
+	add("GitHub API is reachable", err, fmt.Sprintf("Make sure the token can read pull requests in %s/%s and that api.github.com is reachable", owner, repo))
+
+	return diagnoses
+}
+
+// diagnoseCheckers checks that the command of every configured checker can be
+// found, then runs it against an empty import.
+func diagnoseCheckers(config *manifest.Configuration) []diagnosis {
+	diagnoses := make([]diagnosis, 0)
+
+	names := make([]string, 0, len(config.Checkers))
+	for name := range config.Checkers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	check, err := manifest.NewCheck(config, strings.NewReader(""))
+	if err != nil {
+		return append(diagnoses, diagnosis{name: "empty import", err: err})
+	}
+	// Strict checkers fail without pull request information, which the empty
+	// import never has
+	check.Import.Strict = false
+	importJSON, err := check.ImportJSON()
+	if err != nil {
+		return append(diagnoses, diagnosis{name: "empty import", err: err})
+	}
+
+	for _, name := range names {
+		command := config.Checkers[name].Command
+
+		err := resolveCommand(command)
+		diagnoses = append(diagnoses, diagnosis{
+			name: fmt.Sprintf("checker %s: command is found", name),
+			err:  err,
+			hint: fmt.Sprintf("Install the command, or fix the command of %s in manifest.config.yaml", name),
+		})
+		if err != nil {
+			continue
+		}
+
+		result, err := manifest.RunChecker(name, command, importJSON)
+		if err == nil && result.Failure != "" {
+			err = fmt.Errorf("reported a failure: %s", result.Failure)
+		}
+		diagnoses = append(diagnoses, diagnosis{
+			name: fmt.Sprintf("checker %s: runs against an empty import", name),
+			err:  err,
+			hint: fmt.Sprintf("Run the checker by hand to see its output, e.g. `git diff | manifest check --json-only | %s`", command),
+		})
+	}
+
+	return diagnoses
+}
+
+// shellBuiltins are commands that can be run by checkers without being on the
+// PATH.
+var shellBuiltins = map[string]bool{
+	".": true, ":": true, "cd": true, "echo": true, "eval": true, "exec": true,
+	"exit": true, "export": true, "printf": true, "set": true, "test": true,
+	"true": true, "false": true, "[": true,
+}
+
+// resolveCommand returns an error if the program run by the checker command
+// can't be found. Built-in checkers must also exist.
+func resolveCommand(command string) error {
+	program := commandProgram(command)
+	if program == "" {
+		return errors.New("the command is empty")
+	}
+
+	if rest, ok := strings.CutPrefix(strings.TrimSpace(command), "manifest checker "); ok {
+		if _, ok := builtinFor(command); !ok {
+			return fmt.Errorf("%s is not a built-in checker", strings.TrimSpace(rest))
+		}
+	}
+
+	if shellBuiltins[program] {
+		return nil
+	}
+
+	if !strings.Contains(program, "/") {
+		_, err := exec.LookPath(program)
+		if err != nil {
+			return fmt.Errorf("%s was not found on the PATH", program)
+		}
+		return nil
+	}
+
+	info, err := os.Stat(filepath.FromSlash(program))
+	if err != nil {
+		return fmt.Errorf("%s does not exist", program)
+	}
+	if info.IsDir() || info.Mode().Perm()&0o111 == 0 {
+		return fmt.Errorf("%s is not executable", program)
+	}
+
+	return nil
+}
+
+// commandProgram returns the program run by a shell command, skipping leading
+// environment variable assignments.
+func commandProgram(command string) string {
+	for _, field := range strings.Fields(command) {
+		name, _, isAssignment := strings.Cut(field, "=")
+		if isAssignment && name != "" && !strings.ContainsAny(name, `/'"`) {
+			continue
+		}
+
+		return strings.Trim(field, `'"`)
+	}
+
+	return ""
+}
+
+// writeDiagnoses prints the diagnoses, returning how many failed.
+func writeDiagnoses(out io.Writer, diagnoses []diagnosis) int {
+	failed := 0
+	for _, d := range diagnoses {
+		if d.err == nil {
+			fmt.Fprintf(out, "%s %s\n", color.New(color.FgGreen).Sprint("✓"), d.name)
+			continue
+		}
+
+		failed++
+		fmt.Fprintf(out, "%s %s\n", color.New(color.FgRed).Sprint("✗"), d.name)
+		fmt.Fprintf(out, "    %s\n", d.err)
+		if d.hint != "" {
+			fmt.Fprintf(out, "    %s %s\n", color.New(color.Bold).Sprint("hint:"), d.hint)
+		}
+	}
+
+	return failed
+}
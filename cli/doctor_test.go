@@ -0,0 +1,99 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/blakewilliams/manifest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommandProgram(t *testing.T) {
+	require.Equal(t, "script/lint", commandProgram("script/lint --all"))
+	require.Equal(t, "bundle", commandProgram("RAILS_ENV=test FOO= bundle exec rubocop"))
+	require.Equal(t, "./lint.sh", commandProgram(`"./lint.sh" a`))
+	require.Equal(t, "", commandProgram("  "))
+}
+
+func TestResolveCommand(t *testing.T) {
+	dir := t.TempDir()
+	executable := filepath.Join(dir, "lint")
+	require.NoError(t, os.WriteFile(executable, []byte("#!/bin/sh\n"), 0o755))
+	notExecutable := filepath.Join(dir, "notes")
+	require.NoError(t, os.WriteFile(notExecutable, []byte("#!/bin/sh\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "manifest"), []byte("#!/bin/sh\n"), 0o755))
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	require.NoError(t, resolveCommand("sh -c 'exit 0'"))
+	require.NoError(t, resolveCommand("echo '{}'"))
+	require.NoError(t, resolveCommand(executable+" --all"))
+	require.NoError(t, resolveCommand("manifest checker pull-body"))
+
+	require.ErrorContains(t, resolveCommand(notExecutable), "is not executable")
+	require.ErrorContains(t, resolveCommand(filepath.Join(dir, "missing")), "does not exist")
+	require.ErrorContains(t, resolveCommand("definitely-not-a-real-program"), "was not found on the PATH")
+	require.ErrorContains(t, resolveCommand("manifest checker nope"), "nope is not a built-in checker")
+	require.ErrorContains(t, resolveCommand(""), "the command is empty")
+}
+
+func TestDiagnoseCheckers(t *testing.T) {
+	diagnoses := diagnoseCheckers(&manifest.Configuration{
+		Strict: true,
+		Checkers: map[string]manifest.Checker{
+			"ok":      {Command: `echo '{"comments":[]}'`},
+			"failure": {Command: `echo '{"failure":"boom"}'`},
+			"missing": {Command: "definitely-not-a-real-program"},
+		},
+	})
+
+	names := make([]string, 0, len(diagnoses))
+	failed := make([]string, 0)
+	for _, d := range diagnoses {
+		names = append(names, d.name)
+		if d.err != nil {
+			failed = append(failed, d.name)
+		}
+	}
+
+	require.Equal(t, []string{
+		"checker failure: command is found",
+		"checker failure: runs against an empty import",
+		"checker missing: command is found",
+		"checker ok: command is found",
+		"checker ok: runs against an empty import",
+	}, names)
+	require.Equal(t, []string{
+		"checker failure: runs against an empty import",
+		"checker missing: command is found",
+	}, failed)
+}
+
+func TestWriteDiagnoses(t *testing.T) {
+	var out bytes.Buffer
+	failed := writeDiagnoses(&out, []diagnosis{
+		{name: "git is installed"},
+		{name: "history is complete", err: errors.New("the repository is a shallow clone"), hint: "Run git fetch --unshallow"},
+	})
+
+	require.Equal(t, 1, failed)
+	require.Equal(t, strings.Join([]string{
+		"✓ git is installed",
+		"✗ history is complete",
+		"    the repository is a shallow clone",
+		"    hint: Run git fetch --unshallow",
+		"",
+	}, "\n"), out.String())
+}
+
+func TestDiagnosesExitCode(t *testing.T) {
+	invalidConfig := diagnosis{name: "configuration is valid", err: errors.New("bad yaml"), configuration: true}
+	shallow := diagnosis{name: "history is complete", err: errors.New("the repository is a shallow clone")}
+
+	require.Equal(t, ExitConfigError, diagnosesExitCode([]diagnosis{{name: "git is installed"}, invalidConfig}))
+	require.Equal(t, ExitPlatformError, diagnosesExitCode([]diagnosis{invalidConfig, shallow}))
+	require.Equal(t, ExitPlatformError, diagnosesExitCode([]diagnosis{shallow}))
+}
@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"errors"
+
+	"github.com/blakewilliams/manifest"
+	"github.com/urfave/cli/v2"
+)
+
+// Exit codes returned by manifest. They're stable across releases so CI can
+// retry infrastructure failures without retrying policy failures.
+const (
+	// ExitFindings means checkers reported findings at or above the Error
+	// severity, or reported a failure.
+	ExitFindings = 1
+	// ExitCheckerError means a checker couldn't be run, crashed, or returned
+	// invalid output.
+	ExitCheckerError = 2
+	// ExitConfigError means the configuration file or the command line
+	// arguments are invalid.
+	ExitConfigError = 3
+	// ExitInputError means the diff, patch, or import couldn't be read or
+	// parsed.
+	ExitInputError = 4
+	// ExitPlatformError means GitHub, git, or the local environment failed.
+	ExitPlatformError = 5
+)
+
+// withExitCode returns err with the given exit code, unless it already has
+// one.
+func withExitCode(err error, code int) error {
+	var exitErr cli.ExitCoder
+	if errors.As(err, &exitErr) {
+		return err
+	}
+
+	return cli.Exit(err, code)
+}
+
+// performExitCode returns the exit code for the errors returned by Perform.
+// Checkers failing to run take precedence over formatter failures, which take
+// precedence over failures reported by checkers.
+func performExitCode(errs []error) int {
+	code := ExitFindings
+	for _, err := range errs {
+		var failure *manifest.CheckerFailureError
+		switch {
+		case errors.Is(err, manifest.ErrFormatterFailed):
+			if code != ExitCheckerError {
+				code = ExitPlatformError
+			}
+		case !errors.As(err, &failure):
+			code = ExitCheckerError
+		}
+	}
+
+	return code
+}
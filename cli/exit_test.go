@@ -0,0 +1,34 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/blakewilliams/manifest"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v2"
+)
+
+func TestPerformExitCode(t *testing.T) {
+	failure := &manifest.CheckerFailureError{Checker: "pull-body", Reason: "No description"}
+	formatter := fmt.Errorf("%w: could not post comment", manifest.ErrFormatterFailed)
+	crash := errors.New("`lint` check could not parse output")
+
+	require.Equal(t, ExitFindings, performExitCode([]error{failure, fmt.Errorf("against base: %w", failure)}))
+	require.Equal(t, ExitPlatformError, performExitCode([]error{failure, formatter}))
+	require.Equal(t, ExitCheckerError, performExitCode([]error{crash, formatter}))
+	require.Equal(t, ExitCheckerError, performExitCode([]error{formatter, crash}))
+}
+
+func TestWithExitCode(t *testing.T) {
+	var exitErr cli.ExitCoder
+
+	err := withExitCode(errors.New("boom"), ExitPlatformError)
+	require.ErrorAs(t, err, &exitErr)
+	require.Equal(t, ExitPlatformError, exitErr.ExitCode())
+
+	err = withExitCode(cli.Exit("bad flag", ExitConfigError), ExitPlatformError)
+	require.ErrorAs(t, err, &exitErr)
+	require.Equal(t, ExitConfigError, exitErr.ExitCode())
+}
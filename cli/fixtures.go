@@ -0,0 +1,293 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+
+	"github.com/blakewilliams/manifest"
+	"github.com/blakewilliams/manifest/pkg/linediff"
+	"github.com/fatih/color"
+	"github.com/urfave/cli/v2"
+)
+
+// fixture is a single test case for a checker, stored in
+// `<fixtures>/<checker>/<case>/`.
+type fixture struct {
+	checker string
+	name    string
+	dir     string
+}
+
+func (f fixture) String() string {
+	return f.checker + "/" + f.name
+}
+
+type TestCmd struct {
+	configPath   string
+	fixturesPath string
+	update       bool
+	// checkers limits the fixtures to the given checkers
+	checkers []string
+}
+
+// Run runs every checker against its fixtures and compares the results to
+// the expected results, or updates the expected results.
+func (c *TestCmd) Run() error {
+	manifestConfig := &manifest.Configuration{Checkers: map[string]manifest.Checker{}}
+	if err := applyConfig(c.configPath, manifestConfig); err != nil {
+		return err
+	}
+
+	fixturesPath := c.fixturesPath
+	if fixturesPath == "" {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return cli.Exit("Could not get current working directory", ExitPlatformError)
+		}
+		rootDir, err := findGitDir(cwd)
+		if err != nil {
+			return cli.Exit("manifest test must be run inside of a git repository or passed --fixtures", ExitConfigError)
+		}
+		fixturesPath = filepath.Join(rootDir, fixturesDir)
+	}
+
+	fixtures, err := discoverFixtures(fixturesPath, c.checkers)
+	if err != nil {
+		return cli.Exit(err, ExitPlatformError)
+	}
+	if len(fixtures) == 0 {
+		return cli.Exit(fmt.Sprintf("No fixtures found in %s", fixturesPath), ExitConfigError)
+	}
+
+	failed, updated := 0, 0
+	for _, f := range fixtures {
+		checker, ok := manifestConfig.Checkers[f.checker]
+		if !ok {
+			failed++
+			fmt.Printf("%s %s\n  checker %s is not configured\n", color.New(color.FgRed).Sprint("FAIL"), f, f.checker)
+			continue
+		}
+
+		actual, err := runFixture(f, checker)
+		if err != nil {
+			failed++
+			fmt.Printf("%s %s\n  %s\n", color.New(color.FgRed).Sprint("FAIL"), f, err)
+			continue
+		}
+
+		// Fixtures whose checker errored were skipped above, so their
+		// expected results are never overwritten with the error.
+		expectedPath := filepath.Join(f.dir, "expected.json")
+		if c.update {
+			if err := os.WriteFile(expectedPath, actual, 0o644); err != nil {
+				return cli.Exit(fmt.Sprintf("Could not write %s: %s", expectedPath, err), ExitPlatformError)
+			}
+			updated++
+			fmt.Printf("%s %s\n", color.New(color.FgYellow).Sprint("updated"), f)
+			continue
+		}
+
+		expected, err := readExpected(expectedPath)
+		if err != nil {
+			failed++
+			fmt.Printf("%s %s\n  %s\n", color.New(color.FgRed).Sprint("FAIL"), f, err)
+			continue
+		}
+
+		if !bytes.Equal(expected, actual) {
+			failed++
+			fmt.Printf("%s %s\n", color.New(color.FgRed).Sprint("FAIL"), f)
+			printResultDiff(expected, actual)
+			continue
+		}
+
+		fmt.Printf("%s %s\n", color.New(color.FgGreen).Sprint("ok  "), f)
+	}
+
+	summary := fmt.Sprintf("%d passed, %d failed", len(fixtures)-failed, failed)
+	if c.update {
+		summary = fmt.Sprintf("%d updated, %d failed", updated, failed)
+	}
+	if failed > 0 {
+		return cli.Exit(color.New(color.FgRed).Sprint(summary), ExitFindings)
+	}
+	color.New(color.FgGreen).Fprintln(os.Stderr, summary)
+
+	return nil
+}
+
+// discoverFixtures returns the fixtures in the given directory, optionally
+// limited to the given checkers. Every directory two levels deep is a fixture.
+func discoverFixtures(root string, checkers []string) ([]fixture, error) {
+	checkerDirs, err := os.ReadDir(root)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read fixtures: %w", err)
+	}
+
+	fixtures := make([]fixture, 0)
+	for _, checkerDir := range checkerDirs {
+		if !checkerDir.IsDir() {
+			continue
+		}
+		if len(checkers) > 0 && !slices.Contains(checkers, checkerDir.Name()) {
+			continue
+		}
+
+		caseDirs, err := os.ReadDir(filepath.Join(root, checkerDir.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("could not read fixtures: %w", err)
+		}
+
+		for _, caseDir := range caseDirs {
+			if !caseDir.IsDir() {
+				continue
+			}
+
+			fixtures = append(fixtures, fixture{
+				checker: checkerDir.Name(),
+				name:    caseDir.Name(),
+				dir:     filepath.Join(root, checkerDir.Name(), caseDir.Name()),
+			})
+		}
+	}
+
+	return fixtures, nil
+}
+
+// fixtureImportJSON returns the import for the fixture, either from its
+// import.json or from the first .diff file in the fixture. The pull request
+// information in pull.json, if present, replaces the import's.
+func fixtureImportJSON(f fixture) ([]byte, error) {
+	imp := &manifest.Import{}
+
+	content, err := os.ReadFile(filepath.Join(f.dir, "import.json"))
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(content, imp); err != nil {
+			return nil, fmt.Errorf("could not parse import.json: %w", err)
+		}
+	case errors.Is(err, fs.ErrNotExist):
+		diffs, _ := filepath.Glob(filepath.Join(f.dir, "*.diff"))
+		if len(diffs) == 0 {
+			return nil, errors.New("fixture has no import.json or .diff file")
+		}
+		sort.Strings(diffs)
+
+		diffFile, err := os.Open(diffs[0])
+		if err != nil {
+			return nil, err
+		}
+		defer diffFile.Close()
+
+		imp.Diff, err = manifest.NewDiff(diffFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse %s: %w", filepath.Base(diffs[0]), err)
+		}
+	default:
+		return nil, err
+	}
+
+	content, err = os.ReadFile(filepath.Join(f.dir, "pull.json"))
+	if err == nil {
+		imp.Pull = &manifest.Pull{}
+		if err := json.Unmarshal(content, imp.Pull); err != nil {
+			return nil, fmt.Errorf("could not parse pull.json: %w", err)
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	return json.Marshal(imp)
+}
+
+// runFixture runs the checker against the fixture and returns its normalized
+// result.
+func runFixture(f fixture, checker manifest.Checker) ([]byte, error) {
+	importJSON, err := fixtureImportJSON(f)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := manifest.RunChecker(f.checker, checker.Command, importJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	return normalizeResult(result)
+}
+
+func readExpected(path string) ([]byte, error) {
+	content, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, errors.New("expected.json is missing, run with --update to record it")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var result manifest.Result
+	if err := json.Unmarshal(content, &result); err != nil {
+		return nil, fmt.Errorf("could not parse expected.json: %w", err)
+	}
+
+	return normalizeResult(result)
+}
+
+// normalizeResult returns the result as indented JSON with its comments
+// sorted, so that results can be compared regardless of the order the checker
+// reported comments in.
+func normalizeResult(result manifest.Result) ([]byte, error) {
+	if result.Comments == nil {
+		result.Comments = make([]manifest.Comment, 0)
+	}
+
+	sort.SliceStable(result.Comments, func(a, b int) bool {
+		ca, cb := result.Comments[a], result.Comments[b]
+		if ca.File != cb.File {
+			return ca.File < cb.File
+		}
+		if ca.Line != cb.Line {
+			return ca.Line < cb.Line
+		}
+		if ca.Side != cb.Side {
+			return ca.Side < cb.Side
+		}
+		if ca.Severity != cb.Severity {
+			return ca.Severity < cb.Severity
+		}
+		return ca.Text < cb.Text
+	})
+
+	out, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append(out, '\n'), nil
+}
+
+func printResultDiff(expected []byte, actual []byte) {
+	fmt.Printf("  %s\n  %s\n", color.New(color.FgRed).Sprint("--- expected"), color.New(color.FgGreen).Sprint("+++ actual"))
+
+	for _, line := range linediff.Diff(string(expected), string(actual)) {
+		text := "  " + string(line.Op) + line.Text
+		switch line.Op {
+		case linediff.Delete:
+			text = color.New(color.FgRed).Sprint(text)
+		case linediff.Insert:
+			text = color.New(color.FgGreen).Sprint(text)
+		}
+
+		fmt.Println(text)
+	}
+}
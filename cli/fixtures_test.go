@@ -0,0 +1,68 @@
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/blakewilliams/manifest"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v2"
+)
+
+func TestRunFixture(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "titles", "missing_title")
+	require.NoError(t, os.MkdirAll(dir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "change.diff"), []byte("diff --git a/x b/x\nnew file mode 100644\n--- /dev/null\n+++ b/x\n@@ -0,0 +1 @@\n+ok\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "pull.json"), []byte(`{"title": "WIP"}`), 0o644))
+
+	fixtures, err := discoverFixtures(root, []string{"titles"})
+	require.NoError(t, err)
+	require.Len(t, fixtures, 1)
+	require.Equal(t, "titles/missing_title", fixtures[0].String())
+
+	importJSON, err := fixtureImportJSON(fixtures[0])
+	require.NoError(t, err)
+
+	var imp manifest.Import
+	require.NoError(t, json.Unmarshal(importJSON, &imp))
+	require.Equal(t, "WIP", imp.Pull.Title)
+	require.Equal(t, []string{"x"}, imp.Diff.NewFiles)
+
+	actual, err := runFixture(fixtures[0], manifest.Checker{
+		Command: `echo '{"comments":[{"text":"b","severity":"Warn"},{"text":"a","severity":"Warn"}]}'`,
+	})
+	require.NoError(t, err)
+
+	expected, err := normalizeResult(manifest.Result{Comments: []manifest.Comment{
+		{Text: "a", Severity: manifest.SeverityWarn},
+		{Text: "b", Severity: manifest.SeverityWarn},
+	}})
+	require.NoError(t, err)
+	require.Equal(t, string(expected), string(actual))
+}
+
+func TestTestCmd_UpdateReportsFailures(t *testing.T) {
+	root := t.TempDir()
+	configPath := filepath.Join(root, "manifest.config.yaml")
+	config := "manifest:\n  checkers:\n    ok:\n      command: \"echo '{}'\"\n    broken:\n      command: \"exit 1\"\n"
+	require.NoError(t, os.WriteFile(configPath, []byte(config), 0o644))
+
+	fixtures := filepath.Join(root, "fixtures")
+	for _, checker := range []string{"ok", "broken"} {
+		dir := filepath.Join(fixtures, checker, "default")
+		require.NoError(t, os.MkdirAll(dir, 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "change.diff"), []byte("diff --git a/x b/x\nnew file mode 100644\n--- /dev/null\n+++ b/x\n@@ -0,0 +1 @@\n+ok\n"), 0o644))
+	}
+
+	cmd := &TestCmd{configPath: configPath, fixturesPath: fixtures, update: true}
+	err := cmd.Run()
+
+	var exitErr cli.ExitCoder
+	require.ErrorAs(t, err, &exitErr)
+	require.Equal(t, ExitFindings, exitErr.ExitCode())
+	require.FileExists(t, filepath.Join(fixtures, "ok", "default", "expected.json"))
+	require.NoFileExists(t, filepath.Join(fixtures, "broken", "default", "expected.json"))
+}
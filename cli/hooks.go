@@ -0,0 +1,303 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/blakewilliams/manifest"
+	"github.com/blakewilliams/manifest/githelpers"
+	"github.com/fatih/color"
+	"github.com/urfave/cli/v2"
+)
+
+// hookMarker identifies the hooks installed by manifest, so that hooks written
+// by someone else are never overwritten or removed.
+const hookMarker = "# Installed by manifest, remove with `manifest hooks uninstall`"
+
+// SkipEnv is the environment variable used to skip the hooks. It can be set to
+// 1 to skip every checker, or to a comma separated list of checkers to skip.
+const SkipEnv = "MANIFEST_SKIP"
+
+const (
+	preCommitHook = "pre-commit"
+	prePushHook   = "pre-push"
+)
+
+// zeroSha is the sha git passes to pre-push hooks for refs that don't exist.
+const zeroSha = "0000000000000000000000000000000000000000"
+
+func hookScript(hook string) string {
+	return fmt.Sprintf(`#!/bin/sh
+%s
+if ! command -v manifest >/dev/null 2>&1; then
+  echo "manifest is not installed, skipping the %s hook" >&2
+  exit 0
+fi
+
+exec manifest hooks run %s "$@"
+`, hookMarker, hook, hook)
+}
+
+type HooksCmd struct {
+	hooks []string
+	force bool
+}
+
+// Install writes the hooks into the repository's hooks directory.
+func (c *HooksCmd) Install() error {
+	dir, err := githelpers.HooksDir()
+	if err != nil {
+		return cli.Exit(err, ExitPlatformError)
+	}
+
+	for _, hook := range c.hooks {
+		if err := installHook(dir, hook, c.force); err != nil {
+			return withExitCode(err, ExitPlatformError)
+		}
+
+		color.New(color.FgGreen).Fprintf(os.Stderr, "Installed the %s hook in %s\n", hook, dir)
+	}
+
+	return nil
+}
+
+// Uninstall removes the hooks installed by manifest.
+func (c *HooksCmd) Uninstall() error {
+	dir, err := githelpers.HooksDir()
+	if err != nil {
+		return cli.Exit(err, ExitPlatformError)
+	}
+
+	for _, hook := range c.hooks {
+		removed, err := uninstallHook(dir, hook)
+		if err != nil {
+			return withExitCode(err, ExitPlatformError)
+		}
+
+		if removed {
+			color.New(color.FgGreen).Fprintf(os.Stderr, "Removed the %s hook from %s\n", hook, dir)
+		}
+	}
+
+	return nil
+}
+
+func installHook(dir string, hook string, force bool) error {
+	path := filepath.Join(dir, hook)
+
+	existing, err := os.ReadFile(path)
+	if err == nil && !strings.Contains(string(existing), hookMarker) && !force {
+		return cli.Exit(fmt.Sprintf("%s already has a %s hook that wasn't installed by manifest, pass --force to overwrite it", dir, hook), ExitConfigError)
+	}
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("could not read the existing %s hook: %w", hook, err)
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("could not create the hooks directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(hookScript(hook)), 0o755); err != nil {
+		return fmt.Errorf("could not write the %s hook: %w", hook, err)
+	}
+	// WriteFile doesn't change the mode of existing files
+	if err := os.Chmod(path, 0o755); err != nil {
+		return fmt.Errorf("could not make the %s hook executable: %w", hook, err)
+	}
+
+	return nil
+}
+
+// uninstallHook removes the hook if it was installed by manifest, returning
+// true if it was removed.
+func uninstallHook(dir string, hook string) (bool, error) {
+	path := filepath.Join(dir, hook)
+
+	existing, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("could not read the %s hook: %w", hook, err)
+	}
+	if !strings.Contains(string(existing), hookMarker) {
+		return false, cli.Exit(fmt.Sprintf("the %s hook in %s wasn't installed by manifest, leaving it in place", hook, dir), ExitConfigError)
+	}
+
+	if err := os.Remove(path); err != nil {
+		return false, fmt.Errorf("could not remove the %s hook: %w", hook, err)
+	}
+
+	return true, nil
+}
+
+// skippedCheckers returns whether the hooks should be skipped entirely and
+// otherwise the checkers to skip, based on the value of SkipEnv.
+func skippedCheckers(value string) (bool, []string) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "":
+		return false, nil
+	case "1", "true", "all":
+		return true, nil
+	}
+
+	names := make([]string, 0)
+	for _, name := range strings.Split(value, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+
+	return false, names
+}
+
+// matchesChecker returns true if the pattern matches any of the configured
+// checkers.
+func matchesChecker(config *manifest.Configuration, pattern string) bool {
+	for name := range config.Checkers {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// pushRef is a ref being pushed, as passed to the pre-push hook on stdin.
+type pushRef struct {
+	localRef  string
+	localSha  string
+	remoteRef string
+	remoteSha string
+}
+
+func parsePushRefs(r io.Reader) ([]pushRef, error) {
+	refs := make([]pushRef, 0)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 4 {
+			return nil, fmt.Errorf("unexpected pre-push input: %q", scanner.Text())
+		}
+
+		refs = append(refs, pushRef{localRef: fields[0], localSha: fields[1], remoteRef: fields[2], remoteSha: fields[3]})
+	}
+
+	return refs, scanner.Err()
+}
+
+// RunHook runs the checks for the given hook. The pre-commit hook checks the
+// staged changes, while the pre-push hook checks the commits being pushed to
+// the remote, read from in.
+func (c *CheckCmd) RunHook(hook string, args []string, in io.Reader) error {
+	skipAll, skip := skippedCheckers(os.Getenv(SkipEnv))
+	if skipAll {
+		fmt.Fprintf(os.Stderr, "Skipping manifest since %s is set\n", SkipEnv)
+		return nil
+	}
+
+	config, err := c.configuration()
+	if err != nil {
+		return err
+	}
+
+	// A typo in the environment variable shouldn't block the commit, so
+	// unknown checkers are ignored instead of failing the run.
+	known := make([]string, 0, len(skip))
+	for _, pattern := range skip {
+		if !matchesChecker(config, pattern) {
+			fmt.Fprintf(os.Stderr, "warning: %s includes unknown checker '%s', ignoring it\n", SkipEnv, pattern)
+			continue
+		}
+		known = append(known, pattern)
+	}
+	if err := config.SelectCheckers(nil, known); err != nil {
+		return cli.Exit(err, ExitConfigError)
+	}
+	c.skip = append(c.skip, known...)
+
+	if len(config.Checkers) == 0 {
+		fmt.Fprintln(os.Stderr, "No checkers to run, skipping manifest")
+		return nil
+	}
+
+	switch hook {
+	case preCommitHook:
+		diff, err := githelpers.StagedDiff()
+		if err != nil {
+			return cli.Exit(err, ExitPlatformError)
+		}
+
+		return c.Run(strings.NewReader(diff))
+	case prePushHook:
+		if len(args) == 0 {
+			return cli.Exit("the pre-push hook must be passed the name of the remote", ExitConfigError)
+		}
+
+		refs, err := parsePushRefs(in)
+		if err != nil {
+			return cli.Exit(err, ExitInputError)
+		}
+
+		for _, ref := range refs {
+			if err := c.runPushRef(args[0], ref); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	default:
+		return cli.Exit(fmt.Sprintf("unknown hook %s, expected %s or %s", hook, preCommitHook, prePushHook), ExitConfigError)
+	}
+}
+
+// runPushRef runs the checks against the commits of the ref being pushed that
+// aren't on the remote yet.
+func (c *CheckCmd) runPushRef(remote string, ref pushRef) error {
+	// Deleted refs have nothing to check
+	if ref.localSha == zeroSha {
+		return nil
+	}
+
+	base := ""
+	if ref.remoteSha != zeroSha {
+		// The remote sha is only known locally if it was fetched
+		base, _ = githelpers.MergeBase(ref.remoteSha, ref.localSha)
+	}
+	if base == "" {
+		var err error
+		base, err = githelpers.UnpushedBase(remote, ref.localSha)
+		if err != nil {
+			return cli.Exit(err, ExitPlatformError)
+		}
+	}
+	if base == ref.localSha {
+		return nil
+	}
+
+	diff, err := githelpers.RangeDiff(base, ref.localSha)
+	if err != nil {
+		return cli.Exit(err, ExitPlatformError)
+	}
+
+	fmt.Fprintf(os.Stderr, "Checking %s\n", ref.localRef)
+	// Each ref is checked with its own base and head, so the command is copied
+	// instead of changing the one shared by every ref.
+	refCmd := *c
+	if base != githelpers.EmptyTree {
+		refCmd.base = base
+		refCmd.head = ref.localSha
+	}
+
+	return refCmd.Run(strings.NewReader(diff))
+}
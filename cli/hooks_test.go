@@ -0,0 +1,127 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInstallHook(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "hooks")
+
+	require.NoError(t, installHook(dir, preCommitHook, false))
+	content, err := os.ReadFile(filepath.Join(dir, preCommitHook))
+	require.NoError(t, err)
+	require.Contains(t, string(content), "exec manifest hooks run pre-commit")
+
+	info, err := os.Stat(filepath.Join(dir, preCommitHook))
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0o755), info.Mode().Perm())
+
+	// Reinstalling manifest's own hook is allowed
+	require.NoError(t, installHook(dir, preCommitHook, false))
+
+	removed, err := uninstallHook(dir, preCommitHook)
+	require.NoError(t, err)
+	require.True(t, removed)
+
+	removed, err = uninstallHook(dir, preCommitHook)
+	require.NoError(t, err)
+	require.False(t, removed)
+}
+
+func TestInstallHook_ExistingHook(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, prePushHook), []byte("#!/bin/sh\nmake test\n"), 0o755))
+
+	err := installHook(dir, prePushHook, false)
+	require.ErrorContains(t, err, "wasn't installed by manifest")
+
+	_, err = uninstallHook(dir, prePushHook)
+	require.ErrorContains(t, err, "wasn't installed by manifest")
+
+	require.NoError(t, installHook(dir, prePushHook, true))
+}
+
+func TestParsePushRefs(t *testing.T) {
+	refs, err := parsePushRefs(strings.NewReader(
+		"refs/heads/feature abc123 refs/heads/feature " + zeroSha + "\n\n" +
+			"(delete) " + zeroSha + " refs/heads/old def456\n",
+	))
+	require.NoError(t, err)
+
+	require.Equal(t, []pushRef{
+		{localRef: "refs/heads/feature", localSha: "abc123", remoteRef: "refs/heads/feature", remoteSha: zeroSha},
+		{localRef: "(delete)", localSha: zeroSha, remoteRef: "refs/heads/old", remoteSha: "def456"},
+	}, refs)
+
+	_, err = parsePushRefs(strings.NewReader("refs/heads/main abc123\n"))
+	require.Error(t, err)
+}
+
+func TestSkippedCheckers(t *testing.T) {
+	skipAll, skip := skippedCheckers("")
+	require.False(t, skipAll)
+	require.Empty(t, skip)
+
+	skipAll, _ = skippedCheckers("1")
+	require.True(t, skipAll)
+
+	skipAll, skip = skippedCheckers("no_todos,rails_*")
+	require.False(t, skipAll)
+	require.Equal(t, []string{"no_todos", "rails_*"}, skip)
+}
+
+// newHookRepository creates a repository with a lint checker that always
+// reports an error, changes into it, and returns its path.
+func newHookRepository(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	runGit(t, dir, "init", "--quiet", "--initial-branch", "main")
+	config := `manifest:
+  checkers:
+    lint:
+      command: echo '{"comments":[{"text":"nope","severity":"Error"}]}'
+    ok:
+      command: echo '{}'
+`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "manifest.config.yaml"), []byte(config), 0o644))
+	runGit(t, dir, "add", ".")
+	runGit(t, dir, "commit", "--quiet", "-m", "Add config")
+
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, os.Chdir(cwd)) })
+	require.NoError(t, os.Chdir(dir))
+
+	return dir
+}
+
+func TestRunHook_UnknownSkippedChecker(t *testing.T) {
+	dir := newHookRepository(t)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "app.rb"), []byte("puts 1\n"), 0o644))
+	runGit(t, dir, "add", "app.rb")
+
+	t.Setenv(SkipEnv, "lint, typo")
+	cmd := &CheckCmd{noGH: true, local: true}
+	require.NoError(t, cmd.RunHook(preCommitHook, nil, nil))
+	require.Equal(t, []string{"lint"}, cmd.skip)
+}
+
+func TestRunPushRef_KeepsBase(t *testing.T) {
+	dir := newHookRepository(t)
+	first := runGit(t, dir, "rev-parse", "HEAD")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "app.rb"), []byte("puts 1\n"), 0o644))
+	runGit(t, dir, "add", "app.rb")
+	runGit(t, dir, "commit", "--quiet", "-m", "Add app")
+	second := runGit(t, dir, "rev-parse", "HEAD")
+
+	cmd := &CheckCmd{noGH: true, local: true, skip: []string{"lint"}}
+	require.NoError(t, cmd.runPushRef("origin", pushRef{localRef: "refs/heads/main", localSha: second, remoteRef: "refs/heads/main", remoteSha: first}))
+	require.Empty(t, cmd.base)
+	require.Empty(t, cmd.head)
+}
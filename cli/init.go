@@ -0,0 +1,159 @@
+package cli
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/blakewilliams/manifest"
+	"github.com/blakewilliams/manifest/githelpers"
+	"github.com/blakewilliams/manifest/pkg/pathmatch"
+	"github.com/fatih/color"
+	"github.com/urfave/cli/v2"
+)
+
+const workflowPath = ".github/workflows/manifest.yml"
+
+// workflowTemplate is the GitHub Actions workflow created by --workflow.
+//
+//go:embed templates/workflow.yml
+var workflowTemplate string
+
+var railsJobPattern = pathmatch.MustCompile("app/jobs/**/*_job.rb")
+
+type InitCmd struct {
+	force    bool
+	workflow bool
+}
+
+// repositoryLayout is what `manifest init` detected about the repository.
+type repositoryLayout struct {
+	// Languages are the languages in the repository, most common first.
+	Languages    []string
+	HasWorkflows bool
+	HasRailsJobs bool
+}
+
+// Run writes a manifest.config.yaml, and optionally a GitHub Actions workflow,
+// to the root of the repository based on its layout.
+func (c *InitCmd) Run() error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return cli.Exit("Could not get current working directory", ExitPlatformError)
+	}
+	rootDir, err := findGitDir(cwd)
+	if err != nil {
+		return cli.Exit("manifest init must be run inside of a git repository", ExitConfigError)
+	}
+
+	files, err := githelpers.TrackedFiles(rootDir)
+	if err != nil {
+		return cli.Exit(err, ExitPlatformError)
+	}
+
+	layout := detectLayout(files)
+	if _, err := os.Stat(filepath.Join(rootDir, ".github", "workflows")); err == nil {
+		layout.HasWorkflows = true
+	}
+
+	contents := map[string]string{"manifest.config.yaml": renderConfig(layout)}
+	if c.workflow {
+		contents[workflowPath] = workflowTemplate
+	}
+
+	// Check every file before writing anything so a partial setup isn't left
+	// behind.
+	paths := make([]string, 0, len(contents))
+	for path := range contents {
+		if _, err := os.Stat(filepath.Join(rootDir, path)); err == nil && !c.force {
+			return cli.Exit(fmt.Sprintf("%s already exists, pass --force to overwrite it", path), ExitConfigError)
+		}
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		fullPath := filepath.Join(rootDir, path)
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0o755); err != nil {
+			return cli.Exit(fmt.Sprintf("Could not create directory for %s: %s", path, err), ExitPlatformError)
+		}
+		if err := os.WriteFile(fullPath, []byte(contents[path]), 0o644); err != nil {
+			return cli.Exit(fmt.Sprintf("Could not write %s: %s", path, err), ExitPlatformError)
+		}
+
+		color.New(color.FgGreen).Fprintf(os.Stderr, "Created %s\n", path)
+	}
+
+	return nil
+}
+
+// detectLayout detects the languages and frameworks used by the given files.
+func detectLayout(files []string) repositoryLayout {
+	counts := make(map[string]int)
+	layout := repositoryLayout{}
+
+	for _, file := range files {
+		if language := manifest.DetectLanguage(file, ""); language != "" {
+			counts[language]++
+		}
+		if railsJobPattern.Match(file) {
+			layout.HasRailsJobs = true
+		}
+	}
+
+	for language := range counts {
+		layout.Languages = append(layout.Languages, language)
+	}
+	sort.Slice(layout.Languages, func(a, b int) bool {
+		if counts[layout.Languages[a]] != counts[layout.Languages[b]] {
+			return counts[layout.Languages[a]] > counts[layout.Languages[b]]
+		}
+		return layout.Languages[a] < layout.Languages[b]
+	})
+
+	return layout
+}
+
+// renderConfig returns a commented manifest.config.yaml enabling the built-in
+// checkers that are relevant to the repository.
+func renderConfig(layout repositoryLayout) string {
+	var b strings.Builder
+
+	b.WriteString("# Configuration for manifest, see https://github.com/blakewilliams/manifest\n")
+	if len(layout.Languages) > 0 {
+		fmt.Fprintf(&b, "#\n# Detected languages: %s\n", strings.Join(layout.Languages, ", "))
+	}
+	b.WriteString(`manifest:
+  # How many checkers to run at once
+  concurrency: 4
+  # The formatter to use when --formatter isn't passed
+  formatter: pretty
+  # Remove generated and vendored files from the diff passed to checkers
+  excludeGenerated: true
+  checkers:
+`)
+
+	writeChecker := func(enabled bool, name string, comment string) {
+		prefix := ""
+		if !enabled {
+			prefix = "# "
+		}
+
+		fmt.Fprintf(&b, "    # %s\n", comment)
+		fmt.Fprintf(&b, "    %s%s:\n", prefix, name)
+		fmt.Fprintf(&b, "    %s  command: \"manifest checker %s\"\n", prefix, name)
+	}
+
+	writeChecker(layout.HasRailsJobs, "rails_job_perform", "Ensures Rails job perform arguments are changed safely for rolling deploys")
+	writeChecker(layout.HasWorkflows, "pull-body", "Ensures pull requests have a description")
+
+	b.WriteString(`    # Add your own checkers, see "Writing a custom checker" in the README
+    # my_checker:
+    #   command: "script/my-checker"
+`)
+
+	return b.String()
+}
@@ -0,0 +1,39 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/blakewilliams/manifest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectLayout(t *testing.T) {
+	layout := detectLayout([]string{
+		"Gemfile",
+		"app/jobs/greeter_job.rb",
+		"app/models/user.rb",
+		"script/deploy.sh",
+		"README",
+	})
+
+	require.Equal(t, []string{"Ruby", "Shell"}, layout.Languages)
+	require.True(t, layout.HasRailsJobs)
+}
+
+func TestRenderConfig(t *testing.T) {
+	config := &manifest.Configuration{}
+	err := manifest.ParseConfig(
+		strings.NewReader(renderConfig(repositoryLayout{Languages: []string{"Ruby"}, HasRailsJobs: true})),
+		config,
+		map[string]manifest.Formatter{"pretty": nil},
+	)
+	require.NoError(t, err)
+
+	require.Equal(t, 4, config.Concurrency)
+	require.True(t, config.ExcludeGenerated)
+	require.NotContains(t, renderConfig(repositoryLayout{}), "fetchPullRequestInfo")
+	require.Equal(t, map[string]manifest.Checker{
+		"rails_job_perform": {Command: "manifest checker rails_job_perform"},
+	}, config.Checkers)
+}
@@ -1,78 +1,269 @@
 package cli
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/blakewilliams/manifest"
+	"github.com/blakewilliams/manifest/formatters/annotateformat"
 	"github.com/blakewilliams/manifest/formatters/githubformat"
 	"github.com/blakewilliams/manifest/formatters/prettyformat"
 	"github.com/blakewilliams/manifest/githelpers"
 	"github.com/blakewilliams/manifest/github"
+	"github.com/blakewilliams/manifest/pkg/codeowners"
+	"github.com/blakewilliams/manifest/pkg/gitattributes"
 	"github.com/blakewilliams/manifest/pkg/multierror"
 	"github.com/fatih/color"
 	"github.com/urfave/cli/v2"
 )
 
 type CheckCmd struct {
-	configPath  string
-	diffPath    string
-	jsonOnly    bool
-	concurrency int
-	formatter   string
-	checks      []string
-	strict      bool
-	noGH        bool
-	cCtx        *cli.Context
+	configPath   string
+	diffPath     string
+	importPath   string
+	patchPaths   []string
+	jsonOnly     bool
+	concurrency  int
+	formatter    string
+	only         []string
+	skip         []string
+	run          []string
+	strict       bool
+	noGH         bool
+	base         string
+	head         string
+	perCommit    bool
+	differential bool
+	showFixed    bool
+	// interactive triages the findings in the terminal once the checks ran
+	interactive bool
+	// local skips fetching pull request information from GitHub
+	local bool
+	// pull overrides the pull request information from GitHub, if any
+	pull pullOverrides
+	cCtx *cli.Context
 
 	_githubClient   github.Client
 	_githubPRNumber int
 }
 
 func (c *CheckCmd) Run(in io.Reader) error {
+	if c.interactive && (c.formatter != "" || c.jsonOnly) {
+		return cli.Exit("--interactive can't be used with --formatter or --json-only", ExitConfigError)
+	}
+
+	manifestConfig, err := c.configuration()
+	if err != nil {
+		return err
+	}
+
+	if c.importPath != "" {
+		return c.runImport(manifestConfig)
+	}
+	if len(c.patchPaths) > 0 {
+		return c.runPatches(manifestConfig)
+	}
+
+	check, err := manifest.NewCheck(manifestConfig, in)
+	if err != nil {
+		if err := cli.ShowSubcommandHelp(c.cCtx); err != nil {
+			fmt.Println(err)
+		}
+		fmt.Printf("\n")
+		return cli.Exit(color.New(color.FgRed).Sprint(err.Error()), ExitInputError)
+	}
+
+	if !c.local {
+		if err := c.populateGitHubData(check); err != nil {
+			// If we fail to resolve any GitHub data, we can still run the
+			// checks locally. If we're in strict mode, we should exit with an
+			// error unless the information was provided manually.
+			switch {
+			case c.pull.isSet():
+				// The provided information is used instead
+			case c.strict:
+				return cli.Exit(err, ExitPlatformError)
+			default:
+				fmt.Fprintf(os.Stderr, "warning: could not resolve GitHub PR information: %s\n", err)
+			}
+		}
+	}
+
+	// The commit and base imports share the pull request of the import, so
+	// it's overridden before they're added
+	if err := c.pull.apply(check.Import); err != nil {
+		return withExitCode(err, ExitInputError)
+	}
+
+	if err := c.populateCommits(check); err != nil {
+		if c.strict {
+			return cli.Exit(err, ExitPlatformError)
+		}
+
+		fmt.Fprintf(os.Stderr, "warning: could not resolve commits: %s\n", err)
+	}
+
+	if c.perCommit {
+		if err := c.populateCommitDiffs(check); err != nil {
+			return withExitCode(err, ExitPlatformError)
+		}
+	}
+
+	if c.differential {
+		cleanup, err := c.populateDifferentialBase(manifestConfig, check)
+		if err != nil {
+			return withExitCode(err, ExitPlatformError)
+		}
+		defer cleanup()
+	}
+
+	return c.perform(manifestConfig, check)
+}
+
+// configuration builds the manifest configuration from the config file and
+// the command line flags.
+func (c *CheckCmd) configuration() (*manifest.Configuration, error) {
 	manifestConfig := &manifest.Configuration{
 		Concurrency: 1,
 		Formatter:   prettyformat.New(os.Stdout),
-		Checkers:    map[string]string{},
+		Checkers:    map[string]manifest.Checker{},
+		// Imports larger than 8MB are passed to checkers using a file
+		ImportFileThreshold: 8 << 20,
 	}
 
 	if err := applyConfig(c.configPath, manifestConfig); err != nil {
-		return cli.Exit(err, 1)
+		return nil, withExitCode(err, ExitConfigError)
+	}
+	// Triaging writes suppressions, so they're always applied when triaging
+	if manifestConfig.ApplySuppressions || c.interactive {
+		suppressions, err := loadSuppressions()
+		if err != nil {
+			return nil, cli.Exit(err, ExitConfigError)
+		}
+		manifestConfig.Suppressions = suppressions
 	}
 	if c.noGH {
 		manifestConfig.NoGH = true
 	}
 	if err := c.resolveFormatter(manifestConfig); err != nil {
-		return cli.Exit(err, 1)
+		return nil, withExitCode(err, ExitConfigError)
+	}
+	if err := c.resolveChecks(manifestConfig); err != nil {
+		return nil, withExitCode(err, ExitConfigError)
 	}
-	c.resolveChecks(manifestConfig)
 	if c.concurrency > 0 {
 		manifestConfig.Concurrency = c.concurrency
 	}
 	if c.strict {
 		manifestConfig.Strict = true
 	}
+	if c.showFixed {
+		manifestConfig.ShowFixed = true
+	}
 
-	check, err := manifest.NewCheck(manifestConfig, in)
+	return manifestConfig, nil
+}
+
+// runImport runs the checks against a saved import instead of a diff. The
+// import is passed to the checkers as is, so nothing is fetched from GitHub or
+// the repository.
+func (c *CheckCmd) runImport(manifestConfig *manifest.Configuration) error {
+	switch {
+	case c.diffPath != "" || len(c.patchPaths) > 0:
+		return cli.Exit("--import can't be used with --diff or --patch", ExitConfigError)
+	case c.perCommit || c.differential:
+		return cli.Exit("--import can't be used with --per-commit or --differential", ExitConfigError)
+	}
+
+	var content []byte
+	var err error
+	if c.importPath == "-" {
+		content, err = io.ReadAll(os.Stdin)
+	} else {
+		content, err = os.ReadFile(c.importPath)
+	}
 	if err != nil {
-		color.New(color.FgRed).Println(err.Error())
-		return cli.ShowSubcommandHelp(c.cCtx)
+		return cli.Exit(fmt.Sprintf("Could not read the provided import: %s", err), ExitInputError)
 	}
 
-	if err := c.populateGitHubData(check); err != nil {
-		// If we fail to resolve any GitHub data, we can still run the
-		// checks locally. If we're in strict mode, we should exit with an
-		// error.
-		if c.strict {
-			return cli.Exit(err, 1)
+	imp := &manifest.Import{}
+	if err := json.Unmarshal(content, imp); err != nil {
+		return cli.Exit(fmt.Sprintf("Could not parse the provided import: %s", err), ExitInputError)
+	}
+
+	if err := c.pull.apply(imp); err != nil {
+		return withExitCode(err, ExitInputError)
+	}
+
+	return c.perform(manifestConfig, manifest.NewCheckFromImport(manifestConfig, imp))
+}
+
+// runPatches runs the checks against a patch series instead of a diff. The
+// commits and pull request information come from the patches themselves, so
+// nothing is fetched from GitHub. With --per-commit, the checks are run once
+// per patch.
+func (c *CheckCmd) runPatches(manifestConfig *manifest.Configuration) error {
+	switch {
+	case c.diffPath != "":
+		return cli.Exit("--patch can't be used with --diff", ExitConfigError)
+	case c.differential:
+		return cli.Exit("--patch can't be used with --differential", ExitConfigError)
+	}
+
+	readers := make([]io.Reader, 0, len(c.patchPaths))
+	for _, path := range c.patchPaths {
+		if path == "-" {
+			readers = append(readers, os.Stdin)
+			continue
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return cli.Exit(fmt.Sprintf("Could not open the provided patch file: %s", err), ExitInputError)
 		}
+		defer f.Close()
+
+		readers = append(readers, f)
+	}
+
+	series, err := manifest.ParsePatchSeries(io.MultiReader(readers...), manifestConfig.DiffOptions()...)
+	if err != nil {
+		if err := cli.ShowSubcommandHelp(c.cCtx); err != nil {
+			fmt.Println(err)
+		}
+		fmt.Printf("\n")
+		return cli.Exit(color.New(color.FgRed).Sprint(err.Error()), ExitInputError)
+	}
 
-		fmt.Fprintf(os.Stderr, "warning: could not resolve GitHub PR information: %s\n", err)
+	check := manifest.NewCheckFromPatches(manifestConfig, series)
+	if err := c.pull.apply(check.Import); err != nil {
+		return withExitCode(err, ExitInputError)
+	}
+	if c.perCommit {
+		for _, patch := range series.Patches {
+			check.AddCommit(patch.Commit, patch.Diff)
+		}
+	}
+
+	return c.perform(manifestConfig, check)
+}
+
+func (c *CheckCmd) perform(manifestConfig *manifest.Configuration, check *manifest.Check) error {
+	// Saved imports are already annotated
+	if c.importPath == "" {
+		if err := annotateFromRepository(check); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: could not annotate files: %s\n", err)
+		}
+	}
+	for _, warning := range check.Import.Diff.Warnings {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", warning)
 	}
 
 	// Run the relevant command
@@ -92,18 +283,38 @@ func (c *CheckCmd) Run(in io.Reader) error {
 			fmt.Println(err)
 		}
 		fmt.Printf("\n")
-		return cli.Exit(color.New(color.FgRed).Sprint("No checks were provided. Add one to manifest.config.yaml or passed via --check"), 1)
+		return cli.Exit(color.New(color.FgRed).Sprint("No checks were provided. Add one to manifest.config.yaml or pass one via --run"), ExitConfigError)
 	}
 
-	err = check.Perform()
+	var findings *triageFormatter
+	if c.interactive {
+		if manifestConfig.Suppressions == nil {
+			return cli.Exit("--interactive must be run inside of a git repository", ExitConfigError)
+		}
+
+		findings = &triageFormatter{}
+		manifestConfig.Formatter = findings
+	}
+
+	err := check.Perform()
+
+	if findings != nil && len(findings.findings) > 0 {
+		t := newTriage(manifestConfig.Suppressions.Root, check.Import, manifestConfig.Suppressions.Baseline, findings.findings)
+		if err := runTriage(t); err != nil {
+			return cli.Exit(fmt.Errorf("could not triage the findings: %w", err), ExitPlatformError)
+		}
+		writeTriageSummary(os.Stderr, t.findings)
+	}
+
+	summary := check.Import.Diff.Stats.String()
 
 	if err == nil {
-		color.New(color.FgGreen).Fprintf(os.Stderr, "manifest check passed!\n")
+		color.New(color.FgGreen).Fprintf(os.Stderr, "manifest check passed! (%s)\n", summary)
 		return nil
 	}
 
 	if errors.Is(err, manifest.ErrCheckReportedError) {
-		return cli.Exit(color.New(color.FgRed).Sprint("Manifest check failed due to one or more checkers reporting an error."), 1)
+		return cli.Exit(color.New(color.FgRed).Sprintf("Manifest check failed due to one or more checkers reporting an error. (%s)", summary), ExitFindings)
 	}
 
 	var multiError *multierror.Error
@@ -112,10 +323,14 @@ func (c *CheckCmd) Run(in io.Reader) error {
 			fmt.Fprintf(os.Stderr, "%s %s\n", color.New(color.FgRed).Sprint("Check error:"), err)
 		}
 
-		return cli.Exit(color.New(color.FgRed).Sprint("Manifest check failed due to one or more checkers failing to run successfully."), 1)
+		code := performExitCode(multiError.Unwrap())
+		if code == ExitFindings {
+			return cli.Exit(color.New(color.FgRed).Sprint("Manifest check failed due to one or more checkers reporting a failure."), code)
+		}
+		return cli.Exit(color.New(color.FgRed).Sprint("Manifest check failed due to one or more checkers failing to run successfully."), code)
 	}
 
-	return nil
+	return cli.Exit(err, ExitPlatformError)
 }
 
 func (c *CheckCmd) populateGitHubData(i *manifest.Check) error {
@@ -137,14 +352,137 @@ func (c *CheckCmd) populateGitHubData(i *manifest.Check) error {
 	return i.PopulatePullDetails(client, sha, prNum)
 }
 
-func (c *CheckCmd) resolveChecks(config *manifest.Configuration) {
-	if len(c.checks) > 0 {
-		config.Checkers = make(map[string]string, len(c.checks))
+// populateCommits populates the commits from the local git history when a base
+// is provided, falling back to the pull request commits when only PR
+// information is available.
+func (c *CheckCmd) populateCommits(i *manifest.Check) error {
+	if c.base != "" {
+		return i.PopulateCommits(c.base, c.head)
+	}
 
-		for _, check := range c.checks {
-			config.Checkers[check] = check
+	if i.Import.Pull == nil {
+		return nil
+	}
+
+	client, err := c.GitHubClient()
+	if err != nil {
+		return err
+	}
+
+	return i.PopulatePullCommits(client, i.Import.Pull.Number)
+}
+
+// populateCommitDiffs adds the diff of each commit to the check so that the
+// checkers are run once per commit.
+func (c *CheckCmd) populateCommitDiffs(i *manifest.Check) error {
+	if len(i.Import.Commits) == 0 {
+		return cli.Exit("no commits found to check. Provide --base or PR information to use --per-commit", ExitConfigError)
+	}
+
+	for _, commit := range i.Import.Commits {
+		diff, err := githelpers.CommitDiff(commit.Sha)
+		if err != nil {
+			return err
+		}
+
+		if err := i.AddCommitDiff(commit.Sha, strings.NewReader(diff)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// populateDifferentialBase checks out the merge base of the change into a
+// temporary worktree so that differential checkers can be run against the
+// files touched by the change as they were before it. The returned function
+// removes the worktree.
+func (c *CheckCmd) populateDifferentialBase(manifestConfig *manifest.Configuration, i *manifest.Check) (func(), error) {
+	if c.base == "" {
+		return nil, cli.Exit("--differential requires --base", ExitConfigError)
+	}
+	if c.perCommit {
+		return nil, cli.Exit("--differential can't be combined with --per-commit", ExitConfigError)
+	}
+
+	sha, err := githelpers.MergeBase(c.base, c.head)
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := os.MkdirTemp("", "manifest-base-")
+	if err != nil {
+		return nil, fmt.Errorf("could not create directory for base worktree: %w", err)
+	}
+	if err := githelpers.AddWorktree(dir, sha); err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	cleanup := func() {
+		if err := githelpers.RemoveWorktree(dir); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %s\n", err)
 		}
+		os.RemoveAll(dir)
 	}
+
+	// Only the files touched by the change are checked against the base
+	paths := make([]string, 0, len(i.Import.Diff.Files))
+	for _, file := range i.Import.Diff.Files {
+		if file.Operation != manifest.DiffOperationNew {
+			paths = append(paths, file.OldName)
+		}
+	}
+	sort.Strings(paths)
+
+	attributes, err := gitattributes.Load(dir)
+	if err != nil {
+		cleanup()
+		return nil, err
+	}
+
+	diff, err := manifest.NewScanDiff(os.DirFS(dir), paths, attributes, manifestConfig.DiffOptions()...)
+	if err != nil {
+		cleanup()
+		return nil, err
+	}
+	diff.ApplyAttributes(attributes)
+
+	i.SetDifferentialBase(dir, &manifest.Import{
+		Pull:       i.Import.Pull,
+		CurrentSha: sha,
+		Strict:     i.Import.Strict,
+		Diff:       diff,
+	})
+
+	return cleanup, nil
+}
+
+// resolveChecks selects the configured checkers using --only and --skip, and
+// adds the ad-hoc commands passed to --run. The ad-hoc commands replace the
+// configured checkers unless --only is also passed.
+func (c *CheckCmd) resolveChecks(config *manifest.Configuration) error {
+	// --skip would have nothing to skip since --run replaces the checkers
+	if len(c.run) > 0 && len(c.only) == 0 && len(c.skip) > 0 {
+		return errors.New("--skip can only be used with --run when --only is also passed")
+	}
+
+	if len(c.only) > 0 || len(c.skip) > 0 {
+		if err := config.SelectCheckers(c.only, c.skip); err != nil {
+			return err
+		}
+	}
+
+	if len(c.run) > 0 {
+		if len(c.only) == 0 {
+			config.Checkers = make(map[string]manifest.Checker, len(c.run))
+		}
+
+		for _, command := range c.run {
+			config.Checkers[command] = manifest.Checker{Command: command}
+		}
+	}
+
+	return nil
 }
 
 func (c *CheckCmd) resolveFormatter(config *manifest.Configuration) error {
@@ -156,10 +494,12 @@ func (c *CheckCmd) resolveFormatter(config *manifest.Configuration) error {
 	switch c.formatter {
 	case "pretty":
 		config.Formatter = prettyformat.New(os.Stdout)
+	case "annotate":
+		config.Formatter = annotateformat.New(os.Stdout)
 	case "github":
 		gh, err := c.GitHubClient()
 		if err != nil {
-			return cli.Exit(fmt.Errorf("cannot use GitHub formatter: %w", err), 1)
+			return cli.Exit(fmt.Errorf("cannot use GitHub formatter: %w", err), ExitPlatformError)
 		}
 
 		config.Formatter = githubformat.New(os.Stdout, gh)
@@ -233,13 +573,13 @@ func applyConfig(configArg string, rootConfig *manifest.Configuration) error {
 	if configArg != "" {
 		f, err := os.Open(configArg)
 		if err != nil {
-			return cli.Exit(fmt.Sprintf("Could not open the provided config file: %s", err), 1)
+			return cli.Exit(fmt.Sprintf("Could not open the provided config file: %s", err), ExitConfigError)
 		}
 		defer f.Close()
 
-		err = manifest.ParseConfig(f, rootConfig, map[string]manifest.Formatter{"pretty": prettyformat.New(os.Stdout)})
+		err = manifest.ParseConfig(f, rootConfig, configFormatters())
 		if err != nil {
-			return cli.Exit(fmt.Sprintf("Could not parse the provided config file: %s", err), 1)
+			return cli.Exit(fmt.Sprintf("Could not parse the provided config file: %s", err), ExitConfigError)
 		}
 
 		return nil
@@ -247,11 +587,11 @@ func applyConfig(configArg string, rootConfig *manifest.Configuration) error {
 
 	cwd, err := os.Getwd()
 	if err != nil {
-		return cli.Exit("Could not get current working directory", 1)
+		return cli.Exit("Could not get current working directory", ExitPlatformError)
 	}
 	rootDir, err := findGitDir(cwd)
 	if err != nil && err != os.ErrNotExist {
-		return cli.Exit(fmt.Sprintf("error when looking for root dir: %s", err), 1)
+		return cli.Exit(fmt.Sprintf("error when looking for root dir: %s", err), ExitPlatformError)
 	}
 
 	if err == os.ErrNotExist {
@@ -262,19 +602,91 @@ func applyConfig(configArg string, rootConfig *manifest.Configuration) error {
 	if _, err := os.Stat(configPath); err == nil {
 		f, err := os.Open(configPath)
 		if err != nil {
-			return cli.Exit(fmt.Sprintf("Could not open the config file found in the root folder: %s", err), 1)
+			return cli.Exit(fmt.Sprintf("Could not open the config file found in the root folder: %s", err), ExitConfigError)
 		}
 		defer f.Close()
 
-		err = manifest.ParseConfig(f, rootConfig, map[string]manifest.Formatter{"pretty": prettyformat.New(os.Stdout)})
+		err = manifest.ParseConfig(f, rootConfig, configFormatters())
 		if err != nil {
-			return cli.Exit(fmt.Sprintf("Could not parse the provided config file: %s", err), 1)
+			return cli.Exit(fmt.Sprintf("Could not parse the provided config file: %s", err), ExitConfigError)
 		}
 	}
 
 	return nil
 }
 
+// loadSuppressions returns the suppressions of the repository the current
+// directory is in, using the inline directives and baseline found in it.
+func loadSuppressions() (*manifest.Suppressions, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+
+	rootDir, err := findGitDir(cwd)
+	if err == os.ErrNotExist {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	baseline, err := manifest.LoadBaseline(filepath.Join(rootDir, manifest.BaselinePath))
+	if err != nil {
+		return nil, err
+	}
+
+	return &manifest.Suppressions{Root: rootDir, Baseline: baseline}, nil
+}
+
+// configFormatters returns the formatters that can be set in the config file.
+func configFormatters() map[string]manifest.Formatter {
+	return map[string]manifest.Formatter{
+		"pretty":   prettyformat.New(os.Stdout),
+		"annotate": annotateformat.New(os.Stdout),
+	}
+}
+
+// annotateFromRepository annotates the files in the check with the
+// gitattributes and CODEOWNERS found in the root of the repository, if any.
+func annotateFromRepository(check *manifest.Check) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	rootDir, err := findGitDir(cwd)
+	if err == os.ErrNotExist {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return annotateFromDir(check, rootDir)
+}
+
+// annotateFromDir annotates the files in the check with the gitattributes and
+// CODEOWNERS found in rootDir, if any.
+func annotateFromDir(check *manifest.Check, rootDir string) error {
+	attributes, err := gitattributes.Load(rootDir)
+	if err != nil {
+		return err
+	}
+
+	check.ApplyAttributes(attributes)
+
+	owners, err := codeowners.Load(rootDir)
+	if err != nil {
+		return err
+	}
+	if owners != nil {
+		check.ApplyCodeOwners(owners)
+	}
+
+	return nil
+}
+
 func findGitDir(startDir string) (string, error) {
 	dir := startDir
 	for {
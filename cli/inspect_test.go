@@ -0,0 +1,167 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/blakewilliams/manifest"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v2"
+)
+
+const testPatch = `From 1111111111111111111111111111111111111111 Mon Sep 17 00:00:00 2001
+From: Jane Doe <jane@example.com>
+Date: Tue, 2 Jan 2024 03:04:05 +0000
+Subject: [PATCH 1/2] Capitalize two
+
+diff --git a/a.txt b/a.txt
+index f384549..6addb9b 100644
+--- a/a.txt
++++ b/a.txt
+@@ -1,2 +1,2 @@
+ one
+-two
++TWO
+
+From 2222222222222222222222222222222222222222 Mon Sep 17 00:00:00 2001
+From: Jane Doe <jane@example.com>
+Date: Tue, 2 Jan 2024 03:04:05 +0000
+Subject: [PATCH 2/2] Add three
+
+diff --git a/a.txt b/a.txt
+index 6addb9b..a0f3dac 100644
+--- a/a.txt
++++ b/a.txt
+@@ -1,2 +1,3 @@
+ one
+ TWO
++three
+`
+
+func newPatchCheckCmd(t *testing.T) (*CheckCmd, string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	patchPath := filepath.Join(dir, "series.patch")
+	require.NoError(t, os.WriteFile(patchPath, []byte(testPatch), 0o644))
+	configPath := filepath.Join(dir, "manifest.config.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte("manifest:\n  checkers: {}\n"), 0o644))
+
+	// Every run of the checker appends a line to the log
+	logPath := filepath.Join(dir, "runs.log")
+	return &CheckCmd{
+		configPath: configPath,
+		patchPaths: []string{patchPath},
+		formatter:  "pretty",
+		run:        []string{`cat > /dev/null; echo run >> ` + logPath + `; echo '{}'`},
+	}, logPath
+}
+
+func TestRunPatches_RejectsFlags(t *testing.T) {
+	var exitErr cli.ExitCoder
+
+	c, _ := newPatchCheckCmd(t)
+	c.differential = true
+	err := c.Run(nil)
+	require.ErrorAs(t, err, &exitErr)
+	require.Equal(t, ExitConfigError, exitErr.ExitCode())
+	require.EqualError(t, err, "--patch can't be used with --differential")
+
+	c, _ = newPatchCheckCmd(t)
+	c.diffPath = "changes.diff"
+	err = c.Run(nil)
+	require.ErrorAs(t, err, &exitErr)
+	require.Equal(t, ExitConfigError, exitErr.ExitCode())
+}
+
+func TestRunPatches_PerCommit(t *testing.T) {
+	c, logPath := newPatchCheckCmd(t)
+	c.perCommit = true
+	require.NoError(t, c.Run(nil))
+
+	// Once per patch instead of once for the whole series
+	runs, err := os.ReadFile(logPath)
+	require.NoError(t, err)
+	require.Equal(t, 2, strings.Count(string(runs), "run"))
+}
+
+func TestRunPatches_PerCommitPullOverrides(t *testing.T) {
+	c, logPath := newPatchCheckCmd(t)
+	c.perCommit = true
+	body := "Overridden body"
+	c.pull = pullOverrides{body: &body}
+	c.run = []string{`grep -o '"description":"[^"]*"' >> ` + logPath + `; echo '{}'`}
+	require.NoError(t, c.Run(nil))
+
+	// Every commit's import has the overridden pull request
+	runs, err := os.ReadFile(logPath)
+	require.NoError(t, err)
+	require.Equal(t, 2, strings.Count(string(runs), `"description":"Overridden body"`))
+}
+
+func TestResolveChecks(t *testing.T) {
+	newConfig := func() *manifest.Configuration {
+		return &manifest.Configuration{Checkers: map[string]manifest.Checker{
+			"lint":  {Command: "script/lint"},
+			"tests": {Command: "script/tests"},
+		}}
+	}
+
+	cases := []struct {
+		name    string
+		cmd     *CheckCmd
+		want    []string
+		wantErr string
+	}{
+		{name: "skip", cmd: &CheckCmd{skip: []string{"lint"}}, want: []string{"tests"}},
+		{name: "run replaces checkers", cmd: &CheckCmd{run: []string{"script/other"}}, want: []string{"script/other"}},
+		{name: "run with only", cmd: &CheckCmd{run: []string{"script/other"}, only: []string{"lint"}}, want: []string{"lint", "script/other"}},
+		{name: "run with only and skip", cmd: &CheckCmd{run: []string{"script/other"}, only: []string{"*"}, skip: []string{"tests"}}, want: []string{"lint", "script/other"}},
+		{name: "run with skip", cmd: &CheckCmd{run: []string{"script/other"}, skip: []string{"lint"}}, wantErr: "--skip can only be used with --run when --only is also passed"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			config := newConfig()
+			err := c.cmd.resolveChecks(config)
+			if c.wantErr != "" {
+				require.EqualError(t, err, c.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			names := make([]string, 0, len(config.Checkers))
+			for name := range config.Checkers {
+				names = append(names, name)
+			}
+			require.ElementsMatch(t, c.want, names)
+		})
+	}
+}
+
+func TestConfiguration_Suppressions(t *testing.T) {
+	cases := []struct {
+		name        string
+		config      string
+		interactive bool
+		want        bool
+	}{
+		{name: "default", config: "manifest:\n  checkers: {}\n", want: false},
+		{name: "config", config: "manifest:\n  applySuppressions: true\n  checkers: {}\n", want: true},
+		{name: "interactive", config: "manifest:\n  checkers: {}\n", interactive: true, want: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "manifest.config.yaml")
+			require.NoError(t, os.WriteFile(configPath, []byte(c.config), 0o644))
+
+			cmd := &CheckCmd{configPath: configPath, interactive: c.interactive}
+			config, err := cmd.configuration()
+			require.NoError(t, err)
+			require.Equal(t, c.want, config.Suppressions != nil)
+		})
+	}
+}
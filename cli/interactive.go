@@ -0,0 +1,699 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/blakewilliams/manifest"
+	"github.com/blakewilliams/manifest/pkg/term"
+	"github.com/fatih/color"
+)
+
+const (
+	enterScreen = "\033[?1049h\033[?25l"
+	leaveScreen = "\033[?25h\033[?1049l"
+	clearScreen = "\033[H\033[2J"
+
+	keyUp   = "\033[A"
+	keyDown = "\033[B"
+)
+
+const triageHelp = "j/k move  e edit  s suppress  b baseline  f fix  q quit"
+
+// triageFormatter collects the comments of every checker so they can be
+// triaged once the checks have finished.
+type triageFormatter struct {
+	mu       sync.Mutex
+	findings []*finding
+}
+
+func (f *triageFormatter) Format(source string, i *manifest.Import, r manifest.Result) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, comment := range r.Comments {
+		f.findings = append(f.findings, &finding{checker: source, comment: comment})
+	}
+
+	return nil
+}
+
+type findingStatus string
+
+const (
+	statusOpen       findingStatus = ""
+	statusSuppressed findingStatus = "suppressed"
+	statusBaselined  findingStatus = "baselined"
+	statusFixed      findingStatus = "fixed"
+)
+
+// finding is a comment left by a checker, along with what was done about it.
+type finding struct {
+	checker string
+	comment manifest.Comment
+	status  findingStatus
+}
+
+type triageAction int
+
+const (
+	actionNone triageAction = iota
+	actionEdit
+	actionQuit
+)
+
+// triage lets findings be reviewed one at a time, opening them in an editor,
+// suppressing them, or applying their suggested fix.
+type triage struct {
+	// root is the directory the commented files are edited in
+	root     string
+	imp      *manifest.Import
+	baseline *manifest.Baseline
+	findings []*finding
+	selected int
+	// message is the outcome of the last action, shown instead of the help
+	message string
+}
+
+func newTriage(root string, imp *manifest.Import, baseline *manifest.Baseline, findings []*finding) *triage {
+	// Checkers run concurrently, so their results arrive in any order
+	sort.SliceStable(findings, func(a, b int) bool {
+		x, y := findings[a], findings[b]
+		if x.checker != y.checker {
+			return x.checker < y.checker
+		}
+		if x.comment.File != y.comment.File {
+			return x.comment.File < y.comment.File
+		}
+		return x.comment.Line < y.comment.Line
+	})
+
+	return &triage{root: root, imp: imp, baseline: baseline, findings: findings}
+}
+
+// runTriage shows the findings in the terminal until the user quits.
+func runTriage(t *triage) error {
+	tty, err := term.Open()
+	if err != nil {
+		return err
+	}
+	defer tty.Close()
+
+	if err := tty.Raw(); err != nil {
+		return err
+	}
+	fmt.Fprint(tty, enterScreen)
+	defer fmt.Fprint(tty, leaveScreen)
+
+	buf := make([]byte, 16)
+	for {
+		width, height, err := tty.Size()
+		if err != nil || width == 0 || height == 0 {
+			width, height = 80, 24
+		}
+		fmt.Fprint(tty, clearScreen+strings.Join(t.render(width, height), "\n"))
+
+		// Escape sequences like the arrow keys arrive in a single read
+		n, err := tty.Read(buf)
+		if err != nil {
+			return err
+		}
+
+		switch t.handle(string(buf[:n])) {
+		case actionQuit:
+			return nil
+		case actionEdit:
+			cmd, err := t.editorCommand()
+			if err != nil {
+				t.message = "error: " + err.Error()
+				continue
+			}
+
+			fmt.Fprint(tty, leaveScreen)
+			if err := tty.Restore(); err != nil {
+				return err
+			}
+
+			cmd.Stdin, cmd.Stdout, cmd.Stderr = tty.File, tty.File, tty.File
+			if err := cmd.Run(); err != nil {
+				t.message = fmt.Sprintf("error: the editor failed: %s", err)
+			}
+
+			if err := tty.Raw(); err != nil {
+				return err
+			}
+			fmt.Fprint(tty, enterScreen)
+		}
+	}
+}
+
+// handle performs the action bound to the key.
+func (t *triage) handle(key string) triageAction {
+	t.message = ""
+
+	switch key {
+	case "j", keyDown, "\033OB":
+		t.move(1)
+	case "k", keyUp, "\033OA":
+		t.move(-1)
+	case "g":
+		t.selected = 0
+	case "G":
+		t.selected = max(len(t.findings)-1, 0)
+	case "e", "\r", "\n":
+		return actionEdit
+	case "s":
+		t.report(t.suppress(), "Suppressed with an inline directive")
+	case "b":
+		t.report(t.addToBaseline(), "Added to "+manifest.BaselinePath)
+	case "f":
+		t.report(t.fix(), "Applied the suggested fix")
+	case "q", "\033", "\x03":
+		return actionQuit
+	}
+
+	return actionNone
+}
+
+func (t *triage) move(delta int) {
+	t.selected = min(max(t.selected+delta, 0), max(len(t.findings)-1, 0))
+}
+
+// report shows the outcome of an action, moving on to the next open finding
+// when it succeeded.
+func (t *triage) report(err error, success string) {
+	if err != nil {
+		t.message = "error: " + err.Error()
+		return
+	}
+
+	t.message = success
+	for n := t.selected + 1; n < len(t.findings); n++ {
+		if t.findings[n].status == statusOpen {
+			t.selected = n
+			return
+		}
+	}
+}
+
+func (t *triage) current() *finding {
+	if t.selected >= len(t.findings) {
+		return nil
+	}
+
+	return t.findings[t.selected]
+}
+
+// editable returns an error if the finding can't be changed in the working
+// tree.
+func editable(f *finding) error {
+	switch {
+	case f == nil:
+		return errors.New("there are no findings")
+	case f.status != statusOpen:
+		return fmt.Errorf("the finding is already %s", f.status)
+	case f.comment.File == "":
+		return errors.New("the finding isn't on a file")
+	case f.comment.Line == 0:
+		return errors.New("the finding isn't on a line")
+	case f.comment.Side == "LEFT":
+		return errors.New("the finding is on a deleted line")
+	case f.comment.Commit != "":
+		return errors.New("the finding is on a commit's diff, not the working tree")
+	}
+
+	return nil
+}
+
+// suppress writes an IgnoreDirective for the checker above the commented line.
+func (t *triage) suppress() error {
+	f := t.current()
+	if err := editable(f); err != nil {
+		return err
+	}
+
+	language := t.language(f.comment.File)
+	directive, ok := manifest.Directive(language, f.checker)
+	if !ok {
+		return fmt.Errorf("can't write a directive in %s, add the finding to the baseline instead", f.comment.File)
+	}
+
+	lineNo := f.comment.Line
+	err := editLines(t.path(f.comment.File), func(lines []string) ([]string, error) {
+		if int(lineNo) > len(lines) {
+			return nil, fmt.Errorf("%s no longer has a line %d", f.comment.File, lineNo)
+		}
+
+		line := lines[lineNo-1]
+		indentation := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+
+		return append(lines[:lineNo-1], append([]string{indentation + directive}, lines[lineNo-1:]...)...), nil
+	})
+	if err != nil {
+		return err
+	}
+
+	t.shift(f.comment.File, lineNo, 1)
+
+	// The directive covers every comment of the checker on the line
+	for _, other := range t.findings {
+		if other.status == statusOpen && other.checker == f.checker && other.comment.File == f.comment.File &&
+			other.comment.Line == f.comment.Line && other.comment.Side != "LEFT" && other.comment.Commit == "" {
+			other.status = statusSuppressed
+		}
+	}
+
+	return nil
+}
+
+// addToBaseline adds the finding to the baseline, which also covers findings
+// that aren't on a line.
+func (t *triage) addToBaseline() error {
+	f := t.current()
+	if f == nil {
+		return errors.New("there are no findings")
+	}
+	if f.status != statusOpen {
+		return fmt.Errorf("the finding is already %s", f.status)
+	}
+
+	t.baseline.Add(f.checker, f.comment)
+	if err := t.baseline.Write(filepath.Join(t.root, manifest.BaselinePath)); err != nil {
+		return err
+	}
+
+	for _, other := range t.findings {
+		if other.status == statusOpen && t.baseline.Contains(other.checker, other.comment) {
+			other.status = statusBaselined
+		}
+	}
+
+	return nil
+}
+
+// fix replaces the commented line with the suggestion of the checker.
+func (t *triage) fix() error {
+	f := t.current()
+	if err := editable(f); err != nil {
+		return err
+	}
+	if f.comment.Suggestion == nil {
+		return errors.New("the checker didn't suggest a fix")
+	}
+
+	replacement := []string{}
+	if *f.comment.Suggestion != "" {
+		replacement = strings.Split(strings.TrimSuffix(*f.comment.Suggestion, "\n"), "\n")
+	}
+
+	lineNo := f.comment.Line
+	err := editLines(t.path(f.comment.File), func(lines []string) ([]string, error) {
+		if int(lineNo) > len(lines) {
+			return nil, fmt.Errorf("%s no longer has a line %d", f.comment.File, lineNo)
+		}
+
+		return append(lines[:lineNo-1], append(replacement, lines[lineNo:]...)...), nil
+	})
+	if err != nil {
+		return err
+	}
+
+	t.shift(f.comment.File, lineNo+1, len(replacement)-1)
+	f.status = statusFixed
+
+	return nil
+}
+
+// shift moves the findings on the lines of the file starting at from by delta
+// lines, after lines were added or removed above them.
+func (t *triage) shift(file string, from uint, delta int) {
+	for _, f := range t.findings {
+		if f.comment.File == file && f.comment.Line >= from && f.comment.Side != "LEFT" && f.comment.Commit == "" {
+			f.comment.Line = uint(int(f.comment.Line) + delta)
+		}
+	}
+}
+
+// editorCommand returns the command opening the finding in $VISUAL or
+// $EDITOR, at the commented line.
+func (t *triage) editorCommand() (*exec.Cmd, error) {
+	f := t.current()
+	if f == nil {
+		return nil, errors.New("there are no findings")
+	}
+	if f.comment.File == "" {
+		return nil, errors.New("the finding isn't on a file")
+	}
+
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+
+	args := []string{"-c", editor + ` "$@"`, editor}
+	if f.comment.Line != 0 && f.comment.Side != "LEFT" && f.comment.Commit == "" {
+		args = append(args, fmt.Sprintf("+%d", f.comment.Line))
+	}
+	args = append(args, t.path(f.comment.File))
+
+	cmd := exec.Command("sh", args...)
+	cmd.Dir = t.root
+
+	return cmd, nil
+}
+
+func (t *triage) path(file string) string {
+	return filepath.Join(t.root, filepath.FromSlash(file))
+}
+
+// language returns the language of the file, detected by the diff when it's
+// part of it.
+func (t *triage) language(file string) string {
+	if f, ok := t.diffFile(file); ok && f.Language != "" {
+		return f.Language
+	}
+
+	lines, _ := readFileLines(t.path(file))
+	firstLine := ""
+	if len(lines) > 0 {
+		firstLine = lines[0]
+	}
+
+	return manifest.DetectLanguage(file, firstLine)
+}
+
+// diffFile returns the file of the diff with the given name. Comments use the
+// new names of files, or the old name of deleted files.
+func (t *triage) diffFile(name string) (manifest.File, bool) {
+	if file, ok := t.imp.Diff.FileByNewName(name); ok {
+		return file, true
+	}
+
+	file, ok := t.imp.Diff.Files[name]
+	return file, ok
+}
+
+// render returns the lines of the screen: the findings grouped by checker and
+// file, a preview of the selected finding, and the help.
+func (t *triage) render(width int, height int) []string {
+	open := 0
+	for _, f := range t.findings {
+		if f.status == statusOpen {
+			open++
+		}
+	}
+
+	screen := []string{
+		color.New(color.Bold).Sprint(truncate(fmt.Sprintf("manifest: %d open of %d findings (%s)", open, len(t.findings), t.imp.Diff.Stats), width)),
+	}
+
+	rows, selectedRow := t.listRows(width)
+	listHeight := max((height-3)/2, 1)
+	start := min(max(selectedRow-listHeight/2, 0), max(len(rows)-listHeight, 0))
+	end := min(start+listHeight, len(rows))
+	screen = append(screen, rows[start:end]...)
+
+	screen = append(screen, color.New(color.Faint).Sprint(strings.Repeat("─", width)))
+
+	previewHeight := max(height-len(screen)-1, 0)
+	preview := t.preview(width, previewHeight)
+	screen = append(screen, preview[:min(len(preview), previewHeight)]...)
+
+	for len(screen) < height-1 {
+		screen = append(screen, "")
+	}
+
+	if t.message != "" {
+		screen = append(screen, color.New(color.FgYellow).Sprint(truncate(t.message, width)))
+	} else {
+		screen = append(screen, color.New(color.Faint).Sprint(truncate(triageHelp, width)))
+	}
+
+	return screen
+}
+
+// listRows returns the rows listing the findings, with a header for each
+// checker and file, and the index of the row of the selected finding.
+func (t *triage) listRows(width int) ([]string, int) {
+	rows := make([]string, 0, len(t.findings)*2)
+	selectedRow := 0
+
+	for n, f := range t.findings {
+		var previous *finding
+		if n > 0 {
+			previous = t.findings[n-1]
+		}
+
+		if previous == nil || previous.checker != f.checker {
+			rows = append(rows, color.New(color.Bold).Sprint(truncate(f.checker, width)))
+		}
+		if previous == nil || previous.checker != f.checker || previous.comment.File != f.comment.File {
+			file := f.comment.File
+			if file == "" {
+				file = "(no file)"
+			}
+			rows = append(rows, color.New(color.FgCyan).Sprint(truncate("  "+file, width)))
+		}
+
+		marker := " "
+		if n == t.selected {
+			marker = "›"
+			selectedRow = len(rows)
+		}
+
+		location := ""
+		if f.comment.Line != 0 {
+			location = fmt.Sprintf("%d", f.comment.Line)
+			if f.comment.Side == "LEFT" {
+				location += "-"
+			}
+		}
+
+		row := fmt.Sprintf("%s %6s  %s", marker, location, firstLine(f.comment.Text))
+		if f.status != statusOpen {
+			row += fmt.Sprintf(" (%s)", f.status)
+		}
+		row = truncate(row, width)
+
+		switch {
+		case n == t.selected:
+			row = color.New(color.ReverseVideo).Sprint(row)
+		case f.status != statusOpen:
+			row = color.New(color.Faint).Sprint(row)
+		default:
+			row = severityColor(f.comment.Severity).Sprint(row)
+		}
+		rows = append(rows, row)
+	}
+
+	if len(rows) == 0 {
+		rows = append(rows, "No findings")
+	}
+
+	return rows, selectedRow
+}
+
+// preview returns the full text of the selected finding followed by the lines
+// around it.
+func (t *triage) preview(width int, height int) []string {
+	f := t.current()
+	if f == nil {
+		return nil
+	}
+
+	title := fmt.Sprintf("%s: %s", severityLabel(f.comment.Severity), f.checker)
+	if f.comment.Rule != "" {
+		title += fmt.Sprintf(" [%s]", f.comment.Rule)
+	}
+	if f.comment.File != "" {
+		title += " on " + f.comment.File
+		if f.comment.Line != 0 {
+			title += fmt.Sprintf(":%d", f.comment.Line)
+		}
+	}
+
+	lines := []string{severityColor(f.comment.Severity).Sprint(truncate(title, width))}
+	for _, line := range strings.Split(strings.TrimRight(f.comment.Text, "\n"), "\n") {
+		lines = append(lines, truncate(line, width))
+	}
+
+	if f.comment.Suggestion != nil {
+		lines = append(lines, "", "Suggested fix:")
+		if *f.comment.Suggestion == "" {
+			lines = append(lines, color.New(color.FgRed).Sprint("  (remove the line)"))
+		}
+		for _, line := range strings.Split(strings.TrimSuffix(*f.comment.Suggestion, "\n"), "\n") {
+			if *f.comment.Suggestion != "" {
+				lines = append(lines, color.New(color.FgGreen).Sprint(truncate("  "+expandTabs(line), width)))
+			}
+		}
+	}
+
+	// Fixed lines no longer hold what was commented on
+	if f.comment.File == "" || f.comment.Line == 0 || f.status == statusFixed {
+		return lines
+	}
+
+	lines = append(lines, "")
+	radius := max((height-len(lines)-1)/2, 1)
+	return append(lines, t.context(f, radius, width)...)
+}
+
+// context returns the lines of the file around the commented line, marking
+// the lines added by the diff.
+func (t *triage) context(f *finding, radius int, width int) []string {
+	file, _ := t.diffFile(f.comment.File)
+
+	// Deleted lines only exist in the diff
+	if f.comment.Side == "LEFT" {
+		for _, line := range file.Left {
+			if line.LineNo == f.comment.Line {
+				row := fmt.Sprintf("> %5d - %s", line.LineNo, expandTabs(strings.TrimSuffix(line.Content, "\n")))
+				return []string{color.New(color.FgRed).Sprint(truncate(row, width))}
+			}
+		}
+
+		return nil
+	}
+
+	source, err := readFileLines(t.path(f.comment.File))
+	if err != nil {
+		return []string{color.New(color.Faint).Sprintf("Could not read %s", f.comment.File)}
+	}
+
+	added := make(map[uint]bool, len(file.Right))
+	for _, line := range file.Right {
+		added[line.LineNo] = true
+	}
+
+	lineNo := int(f.comment.Line)
+	from := max(lineNo-radius, 1)
+	to := min(lineNo+radius, len(source))
+
+	lines := make([]string, 0, to-from+1)
+	for n := from; n <= to; n++ {
+		marker, sign := " ", " "
+		if n == lineNo {
+			marker = ">"
+		}
+		if added[uint(n)] && f.comment.Commit == "" {
+			sign = "+"
+		}
+
+		row := truncate(fmt.Sprintf("%s %5d %s %s", marker, n, sign, expandTabs(source[n-1])), width)
+		switch {
+		case n == lineNo:
+			row = color.New(color.Bold).Sprint(row)
+		case sign == "+":
+			row = color.New(color.FgGreen).Sprint(row)
+		}
+		lines = append(lines, row)
+	}
+
+	return lines
+}
+
+func severityLabel(severity manifest.Severity) string {
+	switch severity {
+	case manifest.SeverityError:
+		return "Error"
+	case manifest.SeverityWarn:
+		return "Warning"
+	default:
+		return "Info"
+	}
+}
+
+// truncate shortens the line to width characters.
+func truncate(line string, width int) string {
+	runes := []rune(line)
+	if len(runes) <= width {
+		return line
+	}
+	if width <= 1 {
+		return string(runes[:max(width, 0)])
+	}
+
+	return string(runes[:width-1]) + "…"
+}
+
+func expandTabs(line string) string {
+	return strings.ReplaceAll(line, "\t", "    ")
+}
+
+// readFileLines returns the lines of the file at path.
+func readFileLines(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	content := strings.TrimSuffix(string(data), "\n")
+	if content == "" {
+		return []string{}, nil
+	}
+
+	return strings.Split(content, "\n"), nil
+}
+
+// editLines rewrites the lines of the file at path, keeping its permissions
+// and trailing newline.
+func editLines(path string, edit func(lines []string) ([]string, error)) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("could not read %s: %w", path, err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("could not read %s: %w", path, err)
+	}
+
+	content := strings.TrimSuffix(string(data), "\n")
+	lines, err := edit(strings.Split(content, "\n"))
+	if err != nil {
+		return err
+	}
+
+	edited := strings.Join(lines, "\n")
+	if strings.HasSuffix(string(data), "\n") && len(lines) > 0 {
+		edited += "\n"
+	}
+
+	if err := os.WriteFile(path, []byte(edited), info.Mode().Perm()); err != nil {
+		return fmt.Errorf("could not write %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// writeTriageSummary prints what was done about the findings once the user
+// quits.
+func writeTriageSummary(out io.Writer, findings []*finding) {
+	counts := make(map[findingStatus]int)
+	for _, f := range findings {
+		counts[f.status]++
+	}
+	if counts[statusOpen] == len(findings) {
+		return
+	}
+
+	parts := make([]string, 0, 3)
+	for _, status := range []findingStatus{statusSuppressed, statusBaselined, statusFixed} {
+		if counts[status] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[status], status))
+		}
+	}
+
+	fmt.Fprintf(out, "Triaged %d of %d findings: %s\n", len(findings)-counts[statusOpen], len(findings), strings.Join(parts, ", "))
+}
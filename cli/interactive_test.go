@@ -0,0 +1,248 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/blakewilliams/manifest"
+	"github.com/fatih/color"
+	"github.com/stretchr/testify/require"
+)
+
+const triageDiff = `diff --git a/app.rb b/app.rb
+index 1a2b3c4..5d6e7f8 100644
+--- a/app.rb
++++ b/app.rb
+@@ -1,3 +1,4 @@
+ class App
++  # TODO: remove
+   def run
+   end
+`
+
+func newTestTriage(t *testing.T, findings ...*finding) *triage {
+	t.Helper()
+
+	noColor := color.NoColor
+	color.NoColor = true
+	t.Cleanup(func() { color.NoColor = noColor })
+
+	root := t.TempDir()
+	source := "class App\n  # TODO: remove\n  def run\n  end\nend\n"
+	require.NoError(t, os.WriteFile(filepath.Join(root, "app.rb"), []byte(source), 0o644))
+
+	diff, err := manifest.NewDiff(strings.NewReader(triageDiff))
+	require.NoError(t, err)
+
+	return newTriage(root, &manifest.Import{Diff: diff}, &manifest.Baseline{}, findings)
+}
+
+// selectFinding selects the finding, since findings are sorted by checker.
+func selectFinding(t *testing.T, tr *triage, f *finding) {
+	t.Helper()
+
+	for n, candidate := range tr.findings {
+		if candidate == f {
+			tr.selected = n
+			return
+		}
+	}
+
+	t.Fatalf("finding %q is not being triaged", f.comment.Text)
+}
+
+func readTestFile(t *testing.T, path string) string {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	return string(data)
+}
+
+func TestTriage_Render(t *testing.T) {
+	suggestion := "  def run!"
+	tr := newTestTriage(t,
+		&finding{checker: "todos", comment: manifest.Comment{File: "app.rb", Line: 2, Side: "RIGHT", Text: "Don't add TODOs", Severity: manifest.SeverityError}},
+		&finding{checker: "naming", comment: manifest.Comment{File: "app.rb", Line: 3, Side: "RIGHT", Text: "Use a bang\nIt mutates", Severity: manifest.SeverityWarn, Rule: "bang", Suggestion: &suggestion}},
+		&finding{checker: "naming", comment: manifest.Comment{Text: "Add a description", Severity: manifest.SeverityInfo}},
+	)
+
+	require.Equal(t, []string{
+		"manifest: 3 open of 3 findings (1 file …",
+		"naming",
+		"  (no file)",
+		"›         Add a description",
+		"  app.rb",
+		"       3  Use a bang",
+		"todos",
+		"────────────────────────────────────────",
+		"Info: naming",
+		"Add a description",
+		"",
+		"",
+		"",
+		"",
+		"",
+		"j/k move  e edit  s suppress  b baselin…",
+	}, tr.render(40, 16))
+
+	tr.handle("j")
+	screen := tr.render(40, 24)
+	require.Equal(t, []string{
+		"Warning: naming [bang] on app.rb:3",
+		"Use a bang",
+		"It mutates",
+		"",
+		"Suggested fix:",
+		"    def run!",
+		"",
+		"      1   class App",
+		"      2 +   # TODO: remove",
+		">     3     def run",
+		"      4     end",
+		"      5   end",
+		"",
+	}, screen[10:len(screen)-1])
+}
+
+func TestTriage_Handle(t *testing.T) {
+	tr := newTestTriage(t,
+		&finding{checker: "a", comment: manifest.Comment{Text: "first"}},
+		&finding{checker: "b", comment: manifest.Comment{Text: "second"}},
+	)
+
+	require.Equal(t, actionNone, tr.handle(keyDown))
+	require.Equal(t, 1, tr.selected)
+	require.Equal(t, actionNone, tr.handle("j"))
+	require.Equal(t, 1, tr.selected)
+	require.Equal(t, actionNone, tr.handle(keyUp))
+	require.Equal(t, 0, tr.selected)
+	require.Equal(t, actionEdit, tr.handle("e"))
+	require.Equal(t, actionQuit, tr.handle("q"))
+
+	tr.handle("s")
+	require.Equal(t, "error: the finding isn't on a file", tr.message)
+	tr.handle("f")
+	require.Equal(t, "error: the finding isn't on a file", tr.message)
+}
+
+func TestTriage_Suppress(t *testing.T) {
+	todo := &finding{checker: "todos", comment: manifest.Comment{File: "app.rb", Line: 2, Side: "RIGHT", Text: "Don't add TODOs"}}
+	later := &finding{checker: "naming", comment: manifest.Comment{File: "app.rb", Line: 3, Side: "RIGHT", Text: "Use a bang"}}
+	deleted := &finding{checker: "naming", comment: manifest.Comment{File: "app.rb", Line: 3, Side: "LEFT", Text: "Removed"}}
+	tr := newTestTriage(t, todo, later, deleted)
+
+	selectFinding(t, tr, todo)
+	tr.handle("s")
+	require.Equal(t, "Suppressed with an inline directive", tr.message)
+	require.Equal(t, statusSuppressed, todo.status)
+	require.Equal(t, uint(3), todo.comment.Line)
+	require.Equal(t, uint(4), later.comment.Line)
+	require.Equal(t, uint(3), deleted.comment.Line)
+
+	path := filepath.Join(tr.root, "app.rb")
+	require.Equal(t, "class App\n  # manifest:ignore todos\n  # TODO: remove\n  def run\n  end\nend\n", readTestFile(t, path))
+
+	suppressions := &manifest.Suppressions{Root: tr.root}
+	require.True(t, suppressions.Suppressed("todos", todo.comment))
+	require.False(t, suppressions.Suppressed("naming", later.comment))
+
+	selectFinding(t, tr, todo)
+	tr.handle("s")
+	require.Equal(t, "error: the finding is already suppressed", tr.message)
+}
+
+func TestTriage_Suppress_NoComments(t *testing.T) {
+	tr := newTestTriage(t, &finding{checker: "todos", comment: manifest.Comment{File: "data.json", Line: 1, Side: "RIGHT", Text: "Bad data"}})
+	require.NoError(t, os.WriteFile(filepath.Join(tr.root, "data.json"), []byte("{}\n"), 0o644))
+
+	tr.handle("s")
+	require.Equal(t, "error: can't write a directive in data.json, add the finding to the baseline instead", tr.message)
+	require.Equal(t, "{}\n", readTestFile(t, filepath.Join(tr.root, "data.json")))
+}
+
+func TestTriage_Suppress_RenamedFile(t *testing.T) {
+	todo := &finding{checker: "todos", comment: manifest.Comment{File: "script/greet", Line: 1, Side: "RIGHT", Text: "Don't add TODOs"}}
+	tr := newTestTriage(t, todo)
+	require.NoError(t, os.MkdirAll(filepath.Join(tr.root, "script"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(tr.root, "script", "greet"), []byte("# TODO: greet\n"), 0o644))
+
+	// The language can't be detected from the file, only the diff knows it
+	tr.imp.Diff.Files["script/hello"] = manifest.File{
+		Name:      "script/greet",
+		OldName:   "script/hello",
+		Operation: manifest.DiffOperationRename,
+		Language:  "Ruby",
+	}
+
+	tr.handle("s")
+	require.Equal(t, "Suppressed with an inline directive", tr.message)
+	require.Equal(t, "# manifest:ignore todos\n# TODO: greet\n", readTestFile(t, filepath.Join(tr.root, "script", "greet")))
+}
+
+func TestTriage_Baseline(t *testing.T) {
+	first := &finding{checker: "todos", comment: manifest.Comment{File: "app.rb", Line: 2, Text: "Don't add TODOs"}}
+	second := &finding{checker: "todos", comment: manifest.Comment{File: "app.rb", Line: 5, Text: "Don't add TODOs"}}
+	other := &finding{checker: "naming", comment: manifest.Comment{Text: "Add a description"}}
+	tr := newTestTriage(t, first, second, other)
+
+	selectFinding(t, tr, second)
+	tr.handle("b")
+	require.Equal(t, "Added to .manifest/baseline.json", tr.message)
+	require.Equal(t, statusBaselined, first.status)
+	require.Equal(t, statusBaselined, second.status)
+	require.Equal(t, statusOpen, other.status)
+
+	baseline, err := manifest.LoadBaseline(filepath.Join(tr.root, manifest.BaselinePath))
+	require.NoError(t, err)
+	require.Equal(t, []manifest.BaselineEntry{{Checker: "todos", File: "app.rb", Text: "Don't add TODOs"}}, baseline.Entries)
+}
+
+func TestTriage_Fix(t *testing.T) {
+	replacement := "  def run!\n    save\n"
+	removal := ""
+	todo := &finding{checker: "todos", comment: manifest.Comment{File: "app.rb", Line: 2, Side: "RIGHT", Text: "Don't add TODOs", Suggestion: &removal}}
+	naming := &finding{checker: "naming", comment: manifest.Comment{File: "app.rb", Line: 3, Side: "RIGHT", Text: "Use a bang", Suggestion: &replacement}}
+	end := &finding{checker: "style", comment: manifest.Comment{File: "app.rb", Line: 5, Side: "RIGHT", Text: "Missing newline"}}
+	tr := newTestTriage(t, todo, naming, end)
+
+	selectFinding(t, tr, todo)
+	tr.handle("f")
+	require.Equal(t, "Applied the suggested fix", tr.message)
+	require.Equal(t, uint(2), naming.comment.Line)
+	require.Equal(t, uint(4), end.comment.Line)
+
+	selectFinding(t, tr, naming)
+	tr.handle("f")
+	require.Equal(t, statusFixed, naming.status)
+	require.Equal(t, uint(5), end.comment.Line)
+	require.Equal(t, "class App\n  def run!\n    save\n  end\nend\n", readTestFile(t, filepath.Join(tr.root, "app.rb")))
+
+	selectFinding(t, tr, end)
+	tr.handle("f")
+	require.Equal(t, "error: the checker didn't suggest a fix", tr.message)
+}
+
+func TestTriage_EditorCommand(t *testing.T) {
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", "code --wait")
+
+	tr := newTestTriage(t, &finding{checker: "todos", comment: manifest.Comment{File: "app.rb", Line: 2, Side: "RIGHT"}})
+
+	cmd, err := tr.editorCommand()
+	require.NoError(t, err)
+	require.Equal(t, []string{"sh", "-c", `code --wait "$@"`, "code --wait", "+2", filepath.Join(tr.root, "app.rb")}, cmd.Args)
+}
+
+func TestWriteTriageSummary(t *testing.T) {
+	var out strings.Builder
+	writeTriageSummary(&out, []*finding{{status: statusFixed}, {status: statusOpen}, {status: statusBaselined}, {status: statusBaselined}})
+	require.Equal(t, "Triaged 3 of 4 findings: 2 baselined, 1 fixed\n", out.String())
+
+	out.Reset()
+	writeTriageSummary(&out, []*finding{{status: statusOpen}})
+	require.Empty(t, out.String())
+}
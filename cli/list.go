@@ -0,0 +1,174 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/blakewilliams/manifest"
+	"github.com/blakewilliams/manifest/checkers"
+	"github.com/urfave/cli/v2"
+)
+
+// checkerEntry is a checker as shown by `manifest list` and `manifest
+// explain`.
+type checkerEntry struct {
+	name       string
+	configured bool
+	checker    manifest.Checker
+}
+
+func (e checkerEntry) enabled() bool {
+	return e.configured && !e.checker.Disabled
+}
+
+// checkerEntries returns the configured checkers followed by the built-in
+// checkers that aren't configured, each sorted by name. Configured checkers
+// running a built-in checker default to its description, paths, and rules.
+func checkerEntries(config *manifest.Configuration) []checkerEntry {
+	entries := make([]checkerEntry, 0, len(config.Checkers)+len(checkers.Builtins))
+	builtinConfigured := make(map[string]bool)
+
+	for name, checker := range config.Checkers {
+		if builtin, ok := builtinFor(checker.Command); ok {
+			builtinConfigured[builtin.Name] = true
+			checker = withBuiltinDefaults(checker, builtin)
+		}
+
+		entries = append(entries, checkerEntry{name: name, configured: true, checker: checker})
+	}
+	sort.Slice(entries, func(a, b int) bool { return entries[a].name < entries[b].name })
+
+	for _, builtin := range checkers.Builtins {
+		if builtinConfigured[builtin.Name] {
+			continue
+		}
+		if _, ok := config.Checkers[builtin.Name]; ok {
+			continue
+		}
+
+		entries = append(entries, checkerEntry{
+			name:    builtin.Name,
+			checker: withBuiltinDefaults(manifest.Checker{Command: builtin.Command()}, builtin),
+		})
+	}
+
+	return entries
+}
+
+// builtinFor returns the built-in checker run by the given command.
+func builtinFor(command string) (checkers.Builtin, bool) {
+	name, ok := strings.CutPrefix(strings.TrimSpace(command), "manifest checker ")
+	if !ok {
+		return checkers.Builtin{}, false
+	}
+
+	return checkers.Lookup(strings.TrimSpace(name))
+}
+
+func withBuiltinDefaults(checker manifest.Checker, builtin checkers.Builtin) manifest.Checker {
+	if checker.Description == "" {
+		checker.Description = builtin.Usage
+	}
+	if checker.Doc == "" {
+		checker.Doc = builtin.Doc
+	}
+	if len(checker.Rules) == 0 {
+		checker.Rules = builtin.Rules
+	}
+
+	return checker
+}
+
+type ListCmd struct {
+	configPath string
+}
+
+// Run prints every configured and built-in checker.
+func (c *ListCmd) Run(out io.Writer) error {
+	config := &manifest.Configuration{Checkers: map[string]manifest.Checker{}}
+	if err := applyConfig(c.configPath, config); err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tCOMMAND\tPATHS\tENABLED\tDESCRIPTION")
+	for _, entry := range checkerEntries(config) {
+		paths := "*"
+		if len(entry.checker.Paths) > 0 {
+			paths = strings.Join(entry.checker.Paths, ",")
+		}
+		enabled := "no"
+		if entry.enabled() {
+			enabled = "yes"
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", entry.name, entry.checker.Command, paths, enabled, entry.checker.Description)
+	}
+
+	return w.Flush()
+}
+
+type ExplainCmd struct {
+	configPath string
+	name       string
+}
+
+// Run prints the documentation and rules of the checker.
+func (c *ExplainCmd) Run(out io.Writer) error {
+	config := &manifest.Configuration{Checkers: map[string]manifest.Checker{}}
+	if err := applyConfig(c.configPath, config); err != nil {
+		return err
+	}
+
+	entries := checkerEntries(config)
+	for _, entry := range entries {
+		if entry.name == c.name {
+			writeExplanation(out, entry)
+			return nil
+		}
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.name)
+	}
+
+	return cli.Exit(fmt.Sprintf("unknown checker '%s', valid checkers are: %s", c.name, strings.Join(names, ", ")), ExitConfigError)
+}
+
+func writeExplanation(out io.Writer, entry checkerEntry) {
+	fmt.Fprintln(out, entry.name)
+	if entry.checker.Description != "" {
+		fmt.Fprintf(out, "\n%s\n", entry.checker.Description)
+	}
+	if doc := strings.TrimSpace(entry.checker.Doc); doc != "" {
+		fmt.Fprintf(out, "\n%s\n", doc)
+	}
+
+	fmt.Fprintf(out, "\nCommand: %s\n", entry.checker.Command)
+	if len(entry.checker.Paths) > 0 {
+		fmt.Fprintf(out, "Paths:   %s\n", strings.Join(entry.checker.Paths, ", "))
+	}
+	switch {
+	case !entry.configured:
+		fmt.Fprintln(out, "Enabled: no, add it to the checkers in manifest.config.yaml")
+	case entry.checker.Disabled:
+		fmt.Fprintln(out, "Enabled: no, select it with --only to run it")
+	default:
+		fmt.Fprintln(out, "Enabled: yes")
+	}
+
+	if len(entry.checker.Rules) == 0 {
+		return
+	}
+
+	fmt.Fprintln(out, "\nRules:")
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	for _, rule := range entry.checker.Rules {
+		fmt.Fprintf(w, "  %s\t%s\n", rule.ID, rule.Description)
+	}
+	w.Flush()
+}
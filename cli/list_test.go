@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/blakewilliams/manifest"
+	"github.com/blakewilliams/manifest/checkers"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckerEntries(t *testing.T) {
+	entries := checkerEntries(&manifest.Configuration{Checkers: map[string]manifest.Checker{
+		"jobs":     {Command: "manifest checker rails_job_perform"},
+		"no_todos": {Command: "script/no-todos", Description: "Disallows TODOs", Disabled: true},
+	}})
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.name)
+	}
+	require.Equal(t, []string{"jobs", "no_todos", "pull-body"}, names)
+
+	rails, _ := checkers.Lookup("rails_job_perform")
+	require.Equal(t, rails.Usage, entries[0].checker.Description)
+	require.Equal(t, rails.Rules, entries[0].checker.Rules)
+	require.True(t, entries[0].enabled())
+	require.False(t, entries[1].enabled())
+	require.False(t, entries[2].enabled())
+}
+
+func TestWriteExplanation(t *testing.T) {
+	var out bytes.Buffer
+	writeExplanation(&out, checkerEntry{
+		name:       "no_todos",
+		configured: true,
+		checker: manifest.Checker{
+			Command:     "script/no-todos",
+			Description: "Disallows TODOs",
+			Rules:       []manifest.Rule{{ID: "todo", Description: "A TODO was added"}},
+		},
+	})
+
+	require.Equal(t, strings.Join([]string{
+		"no_todos",
+		"",
+		"Disallows TODOs",
+		"",
+		"Command: script/no-todos",
+		"Enabled: yes",
+		"",
+		"Rules:",
+		"  todo  A TODO was added",
+		"",
+	}, "\n"), out.String())
+}
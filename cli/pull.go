@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/blakewilliams/manifest"
+	"github.com/urfave/cli/v2"
+)
+
+// pullOverrides is the pull request information provided using --pr-json,
+// --pr-title, --pr-body, and --pr-body-file. It takes precedence over the
+// information fetched from GitHub.
+type pullOverrides struct {
+	jsonPath string
+	title    *string
+	body     *string
+	bodyPath string
+}
+
+func (p pullOverrides) isSet() bool {
+	return p.jsonPath != "" || p.title != nil || p.body != nil || p.bodyPath != ""
+}
+
+// apply overrides the pull request information of the import, creating it if
+// it's missing. The fields in the JSON file are applied first, so the other
+// flags take precedence over it.
+func (p pullOverrides) apply(imp *manifest.Import) error {
+	if !p.isSet() {
+		return nil
+	}
+	if p.body != nil && p.bodyPath != "" {
+		return cli.Exit("--pr-body and --pr-body-file can't be used together", ExitConfigError)
+	}
+
+	pull := &manifest.Pull{}
+	if imp.Pull != nil {
+		*pull = *imp.Pull
+	}
+
+	if p.jsonPath != "" {
+		content, err := os.ReadFile(p.jsonPath)
+		if err != nil {
+			return fmt.Errorf("could not read the pull request JSON: %w", err)
+		}
+		if err := mergePullJSON(pull, content); err != nil {
+			return fmt.Errorf("could not parse the pull request JSON: %w", err)
+		}
+	}
+
+	if p.title != nil {
+		pull.Title = *p.title
+	}
+	if p.body != nil {
+		pull.Description = *p.body
+	}
+	if p.bodyPath != "" {
+		content, err := os.ReadFile(p.bodyPath)
+		if err != nil {
+			return fmt.Errorf("could not read the pull request body: %w", err)
+		}
+		pull.Description = string(content)
+	}
+
+	imp.Pull = pull
+
+	return nil
+}
+
+// mergePullJSON sets the fields present in the JSON on the pull request. Both
+// the import's format and the output of `gh pr view --json title,body,number`,
+// which names the description body, are supported.
+func mergePullJSON(pull *manifest.Pull, content []byte) error {
+	overrides := struct {
+		*manifest.Pull
+		Body *string `json:"body"`
+	}{Pull: pull}
+
+	if err := json.Unmarshal(content, &overrides); err != nil {
+		return err
+	}
+	if overrides.Body != nil {
+		pull.Description = *overrides.Body
+	}
+
+	return nil
+}
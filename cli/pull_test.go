@@ -0,0 +1,46 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/blakewilliams/manifest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPullOverrides(t *testing.T) {
+	dir := t.TempDir()
+	jsonPath := filepath.Join(dir, "pr.json")
+	require.NoError(t, os.WriteFile(jsonPath, []byte(`{"title":"From JSON","body":"Body from gh","number":12}`), 0o644))
+
+	imp := &manifest.Import{Pull: &manifest.Pull{RepoOwner: "blakewilliams", RepoName: "manifest", Title: "From GitHub"}}
+	title := "From flag"
+	err := pullOverrides{jsonPath: jsonPath, title: &title}.apply(imp)
+	require.NoError(t, err)
+
+	require.Equal(t, &manifest.Pull{
+		RepoOwner:   "blakewilliams",
+		RepoName:    "manifest",
+		Number:      12,
+		Title:       "From flag",
+		Description: "Body from gh",
+	}, imp.Pull)
+}
+
+func TestPullOverrides_MissingPull(t *testing.T) {
+	bodyPath := filepath.Join(t.TempDir(), "body.md")
+	require.NoError(t, os.WriteFile(bodyPath, []byte("Adds a README"), 0o644))
+
+	imp := &manifest.Import{}
+	require.NoError(t, pullOverrides{bodyPath: bodyPath}.apply(imp))
+	require.Equal(t, &manifest.Pull{Description: "Adds a README"}, imp.Pull)
+
+	imp = &manifest.Import{}
+	require.NoError(t, pullOverrides{}.apply(imp))
+	require.Nil(t, imp.Pull)
+
+	body := ""
+	err := pullOverrides{body: &body, bodyPath: bodyPath}.apply(imp)
+	require.ErrorContains(t, err, "can't be used together")
+}
@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/blakewilliams/manifest"
+	"github.com/blakewilliams/manifest/githelpers"
+	"github.com/blakewilliams/manifest/pkg/gitattributes"
+	"github.com/urfave/cli/v2"
+)
+
+// Scan runs the checks against every tracked file in the given paths, as if
+// each file was newly added. Results are never posted to GitHub.
+func (c *CheckCmd) Scan(paths []string) error {
+	if c.formatter == "github" {
+		return cli.Exit("scan results can't be posted to GitHub, use the pretty formatter instead", ExitConfigError)
+	}
+
+	manifestConfig, err := c.configuration()
+	if err != nil {
+		return err
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return cli.Exit("Could not get current working directory", ExitPlatformError)
+	}
+	rootDir, err := findGitDir(cwd)
+	if err != nil {
+		return cli.Exit("manifest scan must be run inside of a git repository", ExitConfigError)
+	}
+
+	files, err := githelpers.TrackedFiles(paths...)
+	if err != nil {
+		return cli.Exit(err, ExitPlatformError)
+	}
+
+	attributes, err := gitattributes.Load(rootDir)
+	if err != nil {
+		return cli.Exit(fmt.Sprintf("Could not load gitattributes: %s", err), ExitPlatformError)
+	}
+
+	diff, err := manifest.NewScanDiff(os.DirFS(rootDir), files, attributes, manifestConfig.DiffOptions()...)
+	if err != nil {
+		return cli.Exit(err, ExitInputError)
+	}
+
+	return c.perform(manifestConfig, manifest.NewCheckFromDiff(manifestConfig, diff))
+}
@@ -0,0 +1,371 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/blakewilliams/manifest"
+	"github.com/blakewilliams/manifest/formatters/githubformat"
+	"github.com/blakewilliams/manifest/githelpers"
+	"github.com/blakewilliams/manifest/github"
+	"github.com/urfave/cli/v2"
+)
+
+// WebhookSecretEnv is the environment variable holding the secret used to
+// sign webhook deliveries.
+const WebhookSecretEnv = "MANIFEST_WEBHOOK_SECRET"
+
+// maxPayloadSize is the largest payload GitHub delivers.
+const maxPayloadSize = 25 << 20
+
+type ServeCmd struct {
+	addr        string
+	secret      []byte
+	token       string
+	apiURL      string
+	configPath  string
+	workDir     string
+	concurrency int
+	queueSize   int
+	out         io.Writer
+}
+
+// Run receives webhook deliveries until ctx is done, checking the pull
+// requests they refer to using at most concurrency workers.
+func (c *ServeCmd) Run(ctx context.Context) error {
+	if len(c.secret) == 0 {
+		return cli.Exit(fmt.Sprintf("%s must be set to the secret of the webhook", WebhookSecretEnv), ExitConfigError)
+	}
+	if c.token == "" {
+		return cli.Exit(errNoGitHubToken, ExitConfigError)
+	}
+
+	queue := newJobQueue(c.queueSize)
+
+	var workers sync.WaitGroup
+	for range max(c.concurrency, 1) {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for pull, ok := queue.pop(); ok; pull, ok = queue.pop() {
+				c.checkPull(pull)
+			}
+		}()
+	}
+
+	server := &http.Server{Addr: c.addr, Handler: c.handler(queue), ReadHeaderTimeout: 10 * time.Second}
+	errs := make(chan error, 1)
+	go func() {
+		errs <- server.ListenAndServe()
+	}()
+	fmt.Fprintf(c.out, "Listening for webhook deliveries on %s\n", c.addr)
+
+	var err error
+	select {
+	case err = <-errs:
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		err = server.Shutdown(shutdownCtx)
+	}
+
+	// Finish the queued jobs. Deliveries still being handled if the shutdown
+	// timed out are rejected once the queue is closed
+	queue.close()
+	workers.Wait()
+
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return cli.Exit(err, ExitPlatformError)
+	}
+
+	return nil
+}
+
+// handler validates webhook deliveries and queues the pull requests they ask
+// to be checked.
+func (c *ServeCmd) handler(queue *jobQueue) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "webhook deliveries must be POSTed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		payload, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxPayloadSize))
+		if err != nil {
+			http.Error(w, "could not read payload", http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		if err := github.ValidateSignature(c.secret, r.Header.Get(github.SignatureHeader), payload); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		pulls, err := github.PullsForEvent(r.Header.Get(github.EventHeader), payload)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		delivery := r.Header.Get(github.DeliveryHeader)
+		for _, pull := range pulls {
+			if !queue.push(pull) {
+				fmt.Fprintf(c.out, "delivery %s: queue is full, dropping %s\n", delivery, pullName(pull))
+				http.Error(w, "queue is full", http.StatusServiceUnavailable)
+				return
+			}
+
+			fmt.Fprintf(c.out, "delivery %s: queued %s\n", delivery, pullName(pull))
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+	})
+}
+
+// checkPull runs the checks against the pull request in a fresh checkout and
+// reports the results on the pull request.
+func (c *ServeCmd) checkPull(pull github.WebhookPull) {
+	start := time.Now()
+
+	err := c.runChecks(pull)
+	switch {
+	case errors.Is(err, errNoServeConfig):
+		fmt.Fprintf(c.out, "%s: skipped, %s\n", pullName(pull), err)
+	case errors.Is(err, manifest.ErrCheckReportedError):
+		fmt.Fprintf(c.out, "%s: checkers reported errors (%s)\n", pullName(pull), time.Since(start).Round(time.Millisecond))
+	case err != nil:
+		fmt.Fprintf(c.out, "%s: check failed: %s\n", pullName(pull), err)
+	default:
+		fmt.Fprintf(c.out, "%s: check passed (%s)\n", pullName(pull), time.Since(start).Round(time.Millisecond))
+	}
+}
+
+var errNoServeConfig = errors.New("the repository has no manifest.config.yaml")
+
+func (c *ServeCmd) runChecks(pull github.WebhookPull) error {
+	client := github.NewClientWithBaseURL(c.apiURL, c.token, pull.Owner, pull.Repo)
+
+	dir, err := os.MkdirTemp(c.workDir, "manifest-serve-")
+	if err != nil {
+		return fmt.Errorf("could not create workspace: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ref := fmt.Sprintf("refs/pull/%d/head", pull.Number)
+	if err := githelpers.Checkout(pull.CloneURL, dir, ref, pull.HeadSha, gitAuthEnv(c.token)); err != nil {
+		return err
+	}
+
+	config, err := c.configuration(dir, pull.BaseSha)
+	if err != nil {
+		return err
+	}
+	config.Formatter = githubformat.New(io.Discard, client)
+
+	diff, err := client.DiffForPull(pull.Number)
+	if err != nil {
+		return fmt.Errorf("could not fetch diff: %w", err)
+	}
+
+	check, err := manifest.NewCheck(config, strings.NewReader(diff))
+	if err != nil {
+		return err
+	}
+	check.Dir = dir
+	check.Env = checkerEnv()
+
+	if err := check.PopulatePullDetails(client, pull.HeadSha, pull.Number); err != nil {
+		return fmt.Errorf("could not fetch pull request details: %w", err)
+	}
+	if err := check.PopulatePullCommits(client, pull.Number); err != nil {
+		return fmt.Errorf("could not fetch pull request commits: %w", err)
+	}
+	if err := annotateFromDir(check, dir); err != nil {
+		return fmt.Errorf("could not annotate files: %w", err)
+	}
+
+	return check.Perform()
+}
+
+// configuration returns the configuration passed with --config, or the one of
+// the repository checked out in dir at the base of the pull request. The
+// configuration of the pull request itself is never used, since it would let
+// anyone opening a pull request choose the commands that are run.
+func (c *ServeCmd) configuration(dir string, baseSha string) (*manifest.Configuration, error) {
+	config := &manifest.Configuration{
+		Concurrency: 1,
+		Checkers:    map[string]manifest.Checker{},
+		// Imports larger than 8MB are passed to checkers using a file
+		ImportFileThreshold: 8 << 20,
+	}
+
+	if c.configPath != "" {
+		if err := applyConfig(c.configPath, config); err != nil {
+			return nil, err
+		}
+	} else {
+		if baseSha == "" {
+			return nil, errors.New("the delivery doesn't include the base of the pull request")
+		}
+
+		content, err := githelpers.FileAtRevision(dir, baseSha, "manifest.config.yaml")
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, errNoServeConfig
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if err := manifest.ParseConfig(bytes.NewReader(content), config, configFormatters()); err != nil {
+			return nil, fmt.Errorf("could not parse manifest.config.yaml: %w", err)
+		}
+	}
+	if len(config.Checkers) == 0 {
+		return nil, errors.New("no checkers are configured")
+	}
+
+	if config.ApplySuppressions {
+		// Like the configuration, the baseline is read from the base of the
+		// pull request so that it can't add its own findings to it
+		baseline, err := baseBaseline(dir, baseSha)
+		if err != nil {
+			return nil, err
+		}
+		config.Suppressions = &manifest.Suppressions{Root: dir, Baseline: baseline}
+	}
+
+	return config, nil
+}
+
+// baseBaseline returns the baseline of the repository checked out in dir at
+// the base of the pull request, or an empty baseline if it has none.
+func baseBaseline(dir string, baseSha string) (*manifest.Baseline, error) {
+	if baseSha == "" {
+		return &manifest.Baseline{}, nil
+	}
+
+	content, err := githelpers.FileAtRevision(dir, baseSha, manifest.BaselinePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &manifest.Baseline{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return manifest.ParseBaseline(content)
+}
+
+// checkerEnvVars are the environment variables of the server passed to
+// checkers. Checkers can run code from the pull request, so everything else,
+// like the GitHub token and the webhook secret, is withheld.
+var checkerEnvVars = []string{"PATH", "HOME", "LANG", "LC_ALL", "TMPDIR", "TZ"}
+
+// checkerEnv returns the environment checkers are run with.
+func checkerEnv() []string {
+	env := make([]string, 0, len(checkerEnvVars))
+	for _, name := range checkerEnvVars {
+		if value, ok := os.LookupEnv(name); ok {
+			env = append(env, name+"="+value)
+		}
+	}
+
+	return env
+}
+
+// gitAuthEnv returns the environment git needs to clone private repositories
+// using token, without exposing it in the arguments of git.
+func gitAuthEnv(token string) []string {
+	credentials := base64.StdEncoding.EncodeToString([]byte("x-access-token:" + token))
+
+	return []string{
+		"GIT_TERMINAL_PROMPT=0",
+		"GIT_CONFIG_COUNT=1",
+		"GIT_CONFIG_KEY_0=http.extraHeader",
+		"GIT_CONFIG_VALUE_0=Authorization: Basic " + credentials,
+	}
+}
+
+func pullName(pull github.WebhookPull) string {
+	return fmt.Sprintf("%s/%s#%d", pull.Owner, pull.Repo, pull.Number)
+}
+
+// jobQueue is a bounded queue of pull requests to check. A pull request is
+// queued at most once, so a pull request pushed to repeatedly is only checked
+// at its latest head.
+type jobQueue struct {
+	mu      sync.Mutex
+	pending map[string]github.WebhookPull
+	keys    chan string
+	closed  bool
+}
+
+func newJobQueue(size int) *jobQueue {
+	return &jobQueue{
+		pending: make(map[string]github.WebhookPull),
+		keys:    make(chan string, max(size, 1)),
+	}
+}
+
+// push queues the pull request, returning false if the queue is full or
+// closed.
+func (q *jobQueue) push(pull github.WebhookPull) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	// Handlers can still be running when the server failed to shut down in
+	// time
+	if q.closed {
+		return false
+	}
+
+	key := pullName(pull)
+	if _, ok := q.pending[key]; ok {
+		q.pending[key] = pull
+		return true
+	}
+
+	select {
+	case q.keys <- key:
+		q.pending[key] = pull
+		return true
+	default:
+		return false
+	}
+}
+
+// pop waits for the next pull request to check, returning false once the
+// queue is closed and empty.
+func (q *jobQueue) pop() (github.WebhookPull, bool) {
+	key, ok := <-q.keys
+	if !ok {
+		return github.WebhookPull{}, false
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	pull := q.pending[key]
+	delete(q.pending, key)
+
+	return pull, true
+}
+
+// close stops the queue, the pull requests already queued are still returned
+// by pop.
+func (q *jobQueue) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.closed = true
+	close(q.keys)
+}
@@ -0,0 +1,251 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/blakewilliams/manifest"
+	"github.com/blakewilliams/manifest/github"
+	"github.com/stretchr/testify/require"
+)
+
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com",
+	)
+	output, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(output))
+
+	return strings.TrimSpace(string(output))
+}
+
+// newPullRemote creates a bare repository with a main branch and a pull
+// request #1 whose head adds a README, returning its path and the base and head
+// shas. The pull request also replaces manifest.config.yaml with headConfig
+// when it's set.
+func newPullRemote(t *testing.T, headConfig string) (string, string, string) {
+	dir := t.TempDir()
+	work := filepath.Join(dir, "work")
+	remote := filepath.Join(dir, "remote.git")
+
+	runGit(t, dir, "init", "--quiet", "--initial-branch", "main", work)
+	config := "manifest:\n  checkers:\n    readme:\n      command: sh checkers/readme.sh\n"
+	require.NoError(t, os.WriteFile(filepath.Join(work, "manifest.config.yaml"), []byte(config), 0o644))
+	require.NoError(t, os.MkdirAll(filepath.Join(work, "checkers"), 0o755))
+	// The checker only passes when run in the checkout, and reports the token
+	// if it can read it
+	checker := `test -f README.md && echo '{"comments":[{"text":"Found a README'"$MANIFEST_GITHUB_TOKEN"'","file":"README.md","line":1,"severity":"Error"}]}'`
+	require.NoError(t, os.WriteFile(filepath.Join(work, "checkers", "readme.sh"), []byte(checker), 0o644))
+	runGit(t, work, "add", ".")
+	runGit(t, work, "commit", "--quiet", "-m", "Add config")
+	base := runGit(t, work, "rev-parse", "HEAD")
+
+	require.NoError(t, os.WriteFile(filepath.Join(work, "README.md"), []byte("# Hello\n"), 0o644))
+	if headConfig != "" {
+		require.NoError(t, os.WriteFile(filepath.Join(work, "manifest.config.yaml"), []byte(headConfig), 0o644))
+	}
+	runGit(t, work, "add", ".")
+	runGit(t, work, "commit", "--quiet", "-m", "Add README")
+	head := runGit(t, work, "rev-parse", "HEAD")
+
+	runGit(t, dir, "clone", "--quiet", "--bare", work, remote)
+	runGit(t, remote, "update-ref", "refs/pull/1/head", head)
+	runGit(t, remote, "update-ref", "refs/heads/main", base)
+
+	return remote, base, head
+}
+
+// fakeGitHub serves the API endpoints used to check a pull request and
+// records the comments created.
+type fakeGitHub struct {
+	mu       sync.Mutex
+	comments []map[string]any
+}
+
+func (f *fakeGitHub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer token" {
+		http.Error(w, "bad credentials", http.StatusUnauthorized)
+		return
+	}
+
+	switch {
+	case r.URL.Path == "/repos/octo/app/pulls/1" && r.Header.Get("Accept") == "application/vnd.github.v3.diff":
+		fmt.Fprint(w, "diff --git a/README.md b/README.md\nnew file mode 100644\n--- /dev/null\n+++ b/README.md\n@@ -0,0 +1 @@\n+# Hello\n")
+	case r.URL.Path == "/repos/octo/app/pulls/1":
+		fmt.Fprint(w, `{"title":"Add README","body":"Adds a README"}`)
+	case r.URL.Path == "/repos/octo/app/pulls/1/commits", r.URL.Path == "/repos/octo/app/issues/1/comments" && r.Method == http.MethodGet:
+		fmt.Fprint(w, `[]`)
+	case r.URL.Path == "/repos/octo/app/pulls/1/comments" && r.Method == http.MethodGet:
+		fmt.Fprint(w, `[]`)
+	case strings.HasSuffix(r.URL.Path, "/comments") && r.Method == http.MethodPost:
+		body, _ := io.ReadAll(r.Body)
+		comment := map[string]any{}
+		_ = json.Unmarshal(body, &comment)
+
+		f.mu.Lock()
+		f.comments = append(f.comments, comment)
+		f.mu.Unlock()
+
+		w.WriteHeader(http.StatusCreated)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func deliver(handler http.Handler, secret []byte, event string, payload string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(payload))
+	req.Header.Set(github.EventHeader, event)
+	req.Header.Set(github.DeliveryHeader, "1")
+	req.Header.Set(github.SignatureHeader, github.Sign(secret, []byte(payload)))
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+
+	return recorder
+}
+
+func TestServe_ChecksPullRequest(t *testing.T) {
+	t.Setenv("MANIFEST_GITHUB_TOKEN", "token")
+	remote, base, head := newPullRemote(t, "")
+
+	api := &fakeGitHub{}
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	var out bytes.Buffer
+	serveCmd := &ServeCmd{
+		secret:  []byte("s3cret"),
+		token:   "token",
+		apiURL:  server.URL,
+		workDir: t.TempDir(),
+		out:     &out,
+	}
+	queue := newJobQueue(10)
+	handler := serveCmd.handler(queue)
+
+	payload := fmt.Sprintf(`{"action":"opened","pull_request":{"number":1,"head":{"sha":%q},"base":{"sha":%q}},"repository":{"name":"app","clone_url":%q,"owner":{"login":"octo"}}}`, head, base, remote)
+	recorder := deliver(handler, serveCmd.secret, "pull_request", payload)
+	require.Equal(t, http.StatusAccepted, recorder.Code)
+
+	// Deliveries for the same pull request are only queued once
+	require.Equal(t, http.StatusAccepted, deliver(handler, serveCmd.secret, "pull_request", payload).Code)
+	queue.close()
+
+	pull, ok := queue.pop()
+	require.True(t, ok)
+	serveCmd.checkPull(pull)
+
+	_, ok = queue.pop()
+	require.False(t, ok)
+
+	require.Contains(t, out.String(), "octo/app#1: checkers reported errors")
+	require.Len(t, api.comments, 1)
+	require.Equal(t, head, api.comments[0]["commit_id"])
+	require.Equal(t, "README.md", api.comments[0]["path"])
+	require.Contains(t, api.comments[0]["body"], "Found a README")
+	// Checkers can't read the token
+	require.NotContains(t, api.comments[0]["body"], "Found a READMEtoken")
+}
+
+func TestServe_IgnoresPullRequestConfig(t *testing.T) {
+	marker := filepath.Join(t.TempDir(), "pwned")
+	headConfig := fmt.Sprintf("manifest:\n  checkers:\n    readme:\n      command: touch %s\n    other:\n      command: touch %s\n", marker, marker)
+	remote, base, head := newPullRemote(t, headConfig)
+
+	api := &fakeGitHub{}
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	var out bytes.Buffer
+	serveCmd := &ServeCmd{token: "token", apiURL: server.URL, workDir: t.TempDir(), out: &out}
+	serveCmd.checkPull(github.WebhookPull{Owner: "octo", Repo: "app", CloneURL: remote, Number: 1, HeadSha: head, BaseSha: base})
+
+	// The checkers of the base branch ran instead of the ones of the pull request
+	require.NoFileExists(t, marker)
+	require.Contains(t, out.String(), "octo/app#1: checkers reported errors")
+	require.Len(t, api.comments, 1)
+	require.Contains(t, api.comments[0]["body"], "Found a README")
+}
+
+func TestServe_RejectsDeliveries(t *testing.T) {
+	serveCmd := &ServeCmd{secret: []byte("s3cret"), out: io.Discard}
+	queue := newJobQueue(1)
+	handler := serveCmd.handler(queue)
+
+	recorder := deliver(handler, []byte("wrong"), "pull_request", `{}`)
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+	recorder = deliver(handler, serveCmd.secret, "ping", `{"zen":"Design for failure."}`)
+	require.Equal(t, http.StatusAccepted, recorder.Code)
+
+	pull := func(number int) string {
+		return fmt.Sprintf(`{"action":"opened","pull_request":{"number":%d},"repository":{"name":"app","owner":{"login":"octo"}}}`, number)
+	}
+	require.Equal(t, http.StatusAccepted, deliver(handler, serveCmd.secret, "pull_request", pull(1)).Code)
+	require.Equal(t, http.StatusServiceUnavailable, deliver(handler, serveCmd.secret, "pull_request", pull(2)).Code)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusMethodNotAllowed, recorder.Code)
+}
+
+func TestJobQueue_Close(t *testing.T) {
+	queue := newJobQueue(2)
+	require.True(t, queue.push(github.WebhookPull{Owner: "octo", Repo: "app", Number: 1}))
+	queue.close()
+
+	// Pushing after the queue is closed is rejected instead of panicking
+	require.False(t, queue.push(github.WebhookPull{Owner: "octo", Repo: "app", Number: 2}))
+
+	pull, ok := queue.pop()
+	require.True(t, ok)
+	require.Equal(t, 1, pull.Number)
+	_, ok = queue.pop()
+	require.False(t, ok)
+}
+
+func TestServe_BaselineFromBase(t *testing.T) {
+	work := t.TempDir()
+	runGit(t, work, "init", "--quiet", "--initial-branch", "main")
+	config := "manifest:\n  applySuppressions: true\n  checkers:\n    readme:\n      command: sh checkers/readme.sh\n"
+	require.NoError(t, os.WriteFile(filepath.Join(work, "manifest.config.yaml"), []byte(config), 0o644))
+	baseline := &manifest.Baseline{Entries: []manifest.BaselineEntry{{Checker: "readme", Text: "Known"}}}
+	require.NoError(t, baseline.Write(filepath.Join(work, manifest.BaselinePath)))
+	runGit(t, work, "add", ".")
+	runGit(t, work, "commit", "--quiet", "-m", "Add config")
+	base := runGit(t, work, "rev-parse", "HEAD")
+
+	// The pull request adds its own finding to the baseline
+	baseline.Add("readme", manifest.Comment{Text: "Found a README"})
+	require.NoError(t, baseline.Write(filepath.Join(work, manifest.BaselinePath)))
+	runGit(t, work, "commit", "--quiet", "-am", "Baseline the README")
+
+	serveCmd := &ServeCmd{}
+	loaded, err := serveCmd.configuration(work, base)
+	require.NoError(t, err)
+	require.NotNil(t, loaded.Suppressions)
+	require.Equal(t, []manifest.BaselineEntry{{Checker: "readme", Text: "Known"}}, loaded.Suppressions.Baseline.Entries)
+
+	// Nothing is suppressed unless the configuration opts in
+	config = "manifest:\n  checkers:\n    readme:\n      command: sh checkers/readme.sh\n"
+	require.NoError(t, os.WriteFile(filepath.Join(work, "manifest.config.yaml"), []byte(config), 0o644))
+	runGit(t, work, "commit", "--quiet", "-am", "Stop applying suppressions")
+	loaded, err = serveCmd.configuration(work, runGit(t, work, "rev-parse", "HEAD"))
+	require.NoError(t, err)
+	require.Nil(t, loaded.Suppressions)
+}
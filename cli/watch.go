@@ -0,0 +1,263 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/blakewilliams/manifest"
+	"github.com/blakewilliams/manifest/githelpers"
+	"github.com/blakewilliams/manifest/pkg/multierror"
+	"github.com/blakewilliams/manifest/pkg/watch"
+	"github.com/fatih/color"
+	"github.com/urfave/cli/v2"
+)
+
+// Watch runs the checks against the changes since the merge base of --base,
+// then re-runs them whenever a file in the working tree changes. Only the
+// checkers whose paths match the changed files are re-run.
+func (c *CheckCmd) Watch(ctx context.Context) error {
+	manifestConfig, err := c.configuration()
+	if err != nil {
+		return err
+	}
+	if len(manifestConfig.Checkers) == 0 {
+		return cli.Exit(color.New(color.FgRed).Sprint("No checks were provided. Add one to manifest.config.yaml or pass one via --run"), ExitConfigError)
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return cli.Exit("Could not get current working directory", ExitPlatformError)
+	}
+	rootDir, err := findGitDir(cwd)
+	if err != nil {
+		return cli.Exit("manifest watch must be run inside of a git repository", ExitConfigError)
+	}
+
+	ignored, err := githelpers.IgnoredDirectories(rootDir)
+	if err != nil {
+		return cli.Exit(err, ExitPlatformError)
+	}
+	skip := func(dir string) bool {
+		return dir == ".git" || matchesAnyDir(ignored, dir)
+	}
+
+	session := &watchSession{
+		config:  manifestConfig,
+		base:    c.base,
+		out:     os.Stdout,
+		results: make(map[string]manifest.Result),
+	}
+	if err := session.run(nil); err != nil {
+		return cli.Exit(err, ExitPlatformError)
+	}
+
+	err = watch.Watch(ctx, rootDir, skip, func(paths []string) {
+		if err := session.run(paths); err != nil {
+			session.errs = []error{err}
+			session.render()
+		}
+	})
+	if err != nil {
+		return cli.Exit(err, ExitPlatformError)
+	}
+
+	return nil
+}
+
+func matchesAnyDir(dirs []string, dir string) bool {
+	for _, ignored := range dirs {
+		if dir == ignored || strings.HasPrefix(dir, ignored+"/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// watchSession holds the latest result of every checker while watching.
+type watchSession struct {
+	config *manifest.Configuration
+	base   string
+	out    io.Writer
+
+	stats   string
+	files   map[string]bool
+	results map[string]manifest.Result
+	errs    []error
+}
+
+// run re-computes the diff and re-runs the checkers affected by the changed
+// paths, or every checker when changed is nil, then redraws the report.
+func (s *watchSession) run(changed []string) error {
+	mergeBase, err := githelpers.MergeBase(s.base, "HEAD")
+	if err != nil {
+		return err
+	}
+	// New files are part of the change before they're added to the index
+	diffText, err := githelpers.DiffWithUntrackedFrom(mergeBase)
+	if err != nil {
+		return err
+	}
+
+	check, err := manifest.NewCheck(s.config, strings.NewReader(diffText))
+	if err != nil {
+		return err
+	}
+	if err := annotateFromRepository(check); err != nil {
+		return fmt.Errorf("could not annotate files: %w", err)
+	}
+	diff := check.Import.Diff
+
+	files := make(map[string]bool, len(diff.Files))
+	for _, file := range diff.Files {
+		files[file.Name] = true
+	}
+
+	// Changes to files that aren't, and weren't, part of the diff, like build
+	// output, don't affect the results.
+	if changed != nil {
+		relevant := make([]string, 0, len(changed))
+		for _, path := range changed {
+			if files[path] || s.files[path] {
+				relevant = append(relevant, path)
+			}
+		}
+		if len(relevant) == 0 {
+			return nil
+		}
+		changed = relevant
+	}
+	s.files = files
+	s.stats = diff.Stats.String()
+
+	checkers := make(map[string]manifest.Checker)
+	for name, checker := range s.config.Checkers {
+		if checker.Disabled || !checker.AppliesTo(diff) {
+			delete(s.results, name)
+			continue
+		}
+
+		if _, ok := s.results[name]; !ok || changed == nil || matchesChangedPaths(checker, changed) {
+			checkers[name] = checker
+		}
+	}
+
+	config := *s.config
+	config.Checkers = checkers
+	formatter := &watchFormatter{results: make(map[string]manifest.Result)}
+	config.Formatter = formatter
+
+	err = manifest.NewCheckFromDiff(&config, diff).Perform()
+
+	s.errs = nil
+	var multiError *multierror.Error
+	if errors.As(err, &multiError) {
+		s.errs = multiError.Unwrap()
+	}
+	for name := range checkers {
+		delete(s.results, name)
+	}
+	for name, result := range formatter.results {
+		s.results[name] = result
+	}
+
+	s.render()
+
+	return nil
+}
+
+// matchesChangedPaths returns true if the checker should be re-run for the
+// changed paths.
+func matchesChangedPaths(checker manifest.Checker, changed []string) bool {
+	if len(checker.Paths) == 0 {
+		return true
+	}
+
+	for _, path := range changed {
+		if checker.MatchesPath(path) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// render clears the terminal and prints a compact report of the results.
+func (s *watchSession) render() {
+	fmt.Fprint(s.out, "\033[H\033[2J")
+	writeWatchReport(s.out, s.base, s.stats, s.results, s.errs, time.Now())
+}
+
+func writeWatchReport(out io.Writer, base string, stats string, results map[string]manifest.Result, errs []error, now time.Time) {
+	fmt.Fprintf(out, "%s %s against %s (%s)\n\n", color.New(color.Bold).Sprint("manifest watch"), stats, base, now.Format("15:04:05"))
+
+	names := make([]string, 0, len(results))
+	for name := range results {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		comments := results[name].Comments
+		if len(comments) == 0 {
+			fmt.Fprintf(out, "%s %s\n", color.New(color.FgGreen).Sprint("✓"), name)
+			continue
+		}
+
+		fmt.Fprintf(out, "%s %s (%d)\n", color.New(color.FgRed).Sprint("✗"), name, len(comments))
+		for _, comment := range comments {
+			location := ""
+			if comment.File != "" && comment.Line != 0 {
+				location = fmt.Sprintf("%s:%d ", comment.File, comment.Line)
+			} else if comment.File != "" {
+				location = comment.File + " "
+			}
+
+			fmt.Fprintf(out, "    %s%s\n", location, severityColor(comment.Severity).Sprint(firstLine(comment.Text)))
+		}
+	}
+
+	for _, err := range errs {
+		fmt.Fprintf(out, "%s %s\n", color.New(color.FgRed).Sprint("Check error:"), err)
+	}
+
+	fmt.Fprintln(out, "\nWatching for changes, press Ctrl-C to stop")
+}
+
+func severityColor(severity manifest.Severity) *color.Color {
+	switch severity {
+	case manifest.SeverityError:
+		return color.New(color.FgRed)
+	case manifest.SeverityWarn:
+		return color.New(color.FgYellow)
+	default:
+		return color.New(color.FgBlue)
+	}
+}
+
+func firstLine(text string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(text), "\n")
+	return line
+}
+
+// watchFormatter records the result of every checker.
+type watchFormatter struct {
+	mu      sync.Mutex
+	results map[string]manifest.Result
+}
+
+func (f *watchFormatter) Format(source string, i *manifest.Import, r manifest.Result) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.results[source] = r
+
+	return nil
+}
@@ -0,0 +1,47 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/blakewilliams/manifest"
+	"github.com/fatih/color"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchesChangedPaths(t *testing.T) {
+	require.True(t, matchesChangedPaths(manifest.Checker{}, []string{"README.md"}))
+	require.True(t, matchesChangedPaths(manifest.Checker{Paths: []string{"app/**/*.rb"}}, []string{"README.md", "app/models/user.rb"}))
+	require.False(t, matchesChangedPaths(manifest.Checker{Paths: []string{"app/**/*.rb"}}, []string{"README.md"}))
+	require.True(t, matchesChangedPaths(manifest.Checker{Paths: []string{"app/"}}, []string{"app/models/user.rb"}))
+	require.True(t, matchesChangedPaths(manifest.Checker{Paths: []string{"app"}}, []string{"app/models/user.rb"}))
+	require.False(t, matchesChangedPaths(manifest.Checker{Paths: []string{"app/"}}, []string{"README.md"}))
+}
+
+func TestWriteWatchReport(t *testing.T) {
+	noColor := color.NoColor
+	color.NoColor = true
+	t.Cleanup(func() { color.NoColor = noColor })
+
+	var out bytes.Buffer
+	writeWatchReport(&out, "main", "1 file changed", map[string]manifest.Result{
+		"todos": {Comments: []manifest.Comment{
+			{File: "app.rb", Line: 3, Text: "TODO found\nmore details", Severity: manifest.SeverityWarn},
+			{Text: "Add a description", Severity: manifest.SeverityError},
+		}},
+		"jobs": {},
+	}, []error{errors.New("lint failed")}, time.Date(2024, 1, 1, 9, 30, 0, 0, time.UTC))
+
+	require.Equal(t, `manifest watch 1 file changed against main (09:30:00)
+
+✓ jobs
+✗ todos (2)
+    app.rb:3 TODO found
+    Add a description
+Check error: lint failed
+
+Watching for changes, press Ctrl-C to stop
+`, out.String())
+}
@@ -8,5 +8,9 @@ import (
 
 func main() {
 	app := cli.New()
-	app.Run(os.Args)
+	if err := app.Run(os.Args); err != nil {
+		// Errors with an exit code exit in Run, the remaining errors are
+		// usage errors that were already printed.
+		os.Exit(cli.ExitConfigError)
+	}
 }
@@ -0,0 +1,86 @@
+package manifest
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Commit represents a single commit that is part of the changes being
+// checked.
+type Commit struct {
+	// Sha is the full sha of the commit
+	Sha string `json:"sha"`
+	// Author is the person who originally wrote the change
+	Author Signature `json:"author"`
+	// Committer is the person who last applied the change
+	Committer Signature `json:"committer"`
+	// Subject is the first line of the commit message
+	Subject string `json:"subject"`
+	// Body is the commit message without the subject
+	Body string `json:"body"`
+	// Trailers are the `Key: value` pairs found at the end of the commit
+	// message, like Signed-off-by or Co-authored-by.
+	Trailers []Trailer `json:"trailers"`
+	// Files is the list of files touched by this commit
+	Files []string `json:"files"`
+}
+
+// Signature identifies the author or committer of a commit.
+type Signature struct {
+	Name  string    `json:"name"`
+	Email string    `json:"email"`
+	Date  time.Time `json:"date"`
+}
+
+// Trailer is a single trailer line in a commit message.
+type Trailer struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+var trailerRegex = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9-]*):\s*(.*)$`)
+
+// parseTrailers returns the trailers in the last paragraph of the given
+// commit message body. Lines starting with whitespace are treated as
+// continuations of the previous trailer.
+func parseTrailers(body string) []Trailer {
+	trailers := make([]Trailer, 0)
+
+	body = strings.TrimRight(body, "\n ")
+	if body == "" {
+		return trailers
+	}
+
+	paragraphs := strings.Split(body, "\n\n")
+	last := paragraphs[len(paragraphs)-1]
+
+	for _, line := range strings.Split(last, "\n") {
+		if line == "" {
+			continue
+		}
+
+		if (line[0] == ' ' || line[0] == '\t') && len(trailers) > 0 {
+			trailers[len(trailers)-1].Value += " " + strings.TrimSpace(line)
+			continue
+		}
+
+		matches := trailerRegex.FindStringSubmatch(line)
+		if matches == nil {
+			// Every line of the paragraph must be a trailer, otherwise it's
+			// just a regular paragraph of the message.
+			return make([]Trailer, 0)
+		}
+
+		trailers = append(trailers, Trailer{Key: matches[1], Value: strings.TrimSpace(matches[2])})
+	}
+
+	return trailers
+}
+
+// splitCommitMessage splits a full commit message into its subject and body.
+func splitCommitMessage(message string) (string, string) {
+	subject, body, _ := strings.Cut(strings.TrimSpace(message), "\n")
+
+	return strings.TrimSpace(subject), strings.TrimSpace(body)
+}
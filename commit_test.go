@@ -0,0 +1,33 @@
+package manifest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTrailers(t *testing.T) {
+	body := `Fixes the thing that was broken.
+
+Signed-off-by: Jane Doe <jane@example.com>
+Co-authored-by: John Doe
+  <john@example.com>`
+
+	trailers := parseTrailers(body)
+
+	require.Len(t, trailers, 2)
+	require.Equal(t, Trailer{Key: "Signed-off-by", Value: "Jane Doe <jane@example.com>"}, trailers[0])
+	require.Equal(t, Trailer{Key: "Co-authored-by", Value: "John Doe <john@example.com>"}, trailers[1])
+}
+
+func TestParseTrailers_NoTrailers(t *testing.T) {
+	require.Empty(t, parseTrailers(""))
+	require.Empty(t, parseTrailers("Just a regular paragraph.\n\nNote: this is prose, not a trailer\nbecause this line isn't one."))
+}
+
+func TestSplitCommitMessage(t *testing.T) {
+	subject, body := splitCommitMessage("fixup! Add feature\n\nSome details\n\nSigned-off-by: Jane <jane@example.com>\n")
+
+	require.Equal(t, "fixup! Add feature", subject)
+	require.Equal(t, "Some details\n\nSigned-off-by: Jane <jane@example.com>", body)
+}
@@ -1,9 +1,14 @@
 package manifest
 
 import (
+	"bytes"
 	"fmt"
 	"io"
+	"path"
+	"sort"
+	"strings"
 
+	"github.com/blakewilliams/manifest/pkg/pathmatch"
 	"gopkg.in/yaml.v3"
 )
 
@@ -27,8 +32,10 @@ type Configuration struct {
 	// Concurrency is the number of checkers to run concurrently.
 	Concurrency int
 	// Formatter is used to output the manifest.Result
-	Formatter     Formatter
-	Checkers      map[string]string
+	Formatter Formatter
+	// Checkers are the configured checkers keyed by name. It was previously a
+	// map of names to commands, which are now Checker.Command.
+	Checkers      map[string]Checker
 	FetchPullInfo bool
 	// Strict determines if certain checkers or functionality should
 	// gracefully degrade based on the environment. e.g. Missing GitHub tokens.
@@ -36,6 +43,111 @@ type Configuration struct {
 	// NoGH determines if the token should be pulled from `gh` if
 	// MANIFEST_GITHUB_TOKEN is not present.
 	NoGH bool
+	// ExcludeGenerated removes generated and vendored files from the diff
+	// passed to every checker.
+	ExcludeGenerated bool
+	// IgnoreMoved prevents error comments on moved lines from failing the
+	// check, since moved code isn't a new change.
+	IgnoreMoved bool
+	// DetectReindentedMoves ignores leading and trailing whitespace when
+	// detecting moved lines, so moved code that was re-indented is still
+	// marked as moved.
+	DetectReindentedMoves bool
+	// MaxDiffBytes is the maximum size of the diff that is read. Files past
+	// the limit are skipped. Zero means no limit.
+	MaxDiffBytes int64
+	// MaxFileLines is the maximum number of added or deleted lines kept for
+	// each file. Larger files are truncated. Zero means no limit.
+	MaxFileLines int
+	// MaxLineLength is the maximum length of each changed line. Longer lines
+	// are truncated. Zero means no limit.
+	MaxLineLength int
+	// ImportFileThreshold is the size of the import JSON above which it is
+	// written to a temporary file that is passed to each checker, instead of
+	// being kept in memory. Zero disables the temporary file.
+	ImportFileThreshold int64
+	// ShowFixed reports the findings fixed by the change as Info comments when
+	// running differential checkers.
+	ShowFixed bool
+	// ApplySuppressions removes the comments suppressed with inline
+	// directives or listed in the baseline. Suppressions are opt-in since
+	// they let a change silence the checkers run against it.
+	ApplySuppressions bool
+	// Suppressions removes the comments suppressed by the user from the
+	// results. Nothing is suppressed when it's nil.
+	Suppressions *Suppressions
+}
+
+// Checker is a configured checker.
+type Checker struct {
+	// Command is the shell command that runs the checker.
+	Command string
+	// Differential is true if the checker reports on whole files, so it should
+	// also be run against the base of the change to only report the findings
+	// introduced by the change when running in differential mode.
+	Differential bool
+	// Description is a one line summary of what the checker enforces.
+	Description string
+	// Doc is the long-form documentation of the checker.
+	Doc string
+	// Paths are globs limiting the checker to diffs touching a matching file.
+	// Entries naming a directory, like `app/` or `app`, match the files in it.
+	// The checker always runs when it's empty.
+	Paths []string
+	// Disabled checkers only run when selected with --only.
+	Disabled bool
+	// Rules are the rules the checker can report comments for.
+	Rules []Rule
+}
+
+// AppliesTo returns true if the checker should run for the given diff based
+// on its paths.
+func (c Checker) AppliesTo(diff Diff) bool {
+	if len(c.Paths) == 0 {
+		return true
+	}
+
+	for _, file := range diff.Files {
+		if c.MatchesPath(file.path()) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// MatchesPath returns true if the file at path matches the checker's paths,
+// or the checker has none.
+func (c Checker) MatchesPath(path string) bool {
+	if len(c.Paths) == 0 {
+		return true
+	}
+
+	for _, glob := range c.Paths {
+		// Invalid paths are rejected by ParseConfig, but the checker runs
+		// rather than being skipped silently if they were set some other way
+		pattern, err := pathmatch.Compile(glob)
+		if err != nil || pattern.MatchOrParent(path) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// DiffOptions returns the options used to parse diffs based on the configured
+// limits.
+func (c *Configuration) DiffOptions() []DiffOption {
+	opts := []DiffOption{
+		WithMaxDiffBytes(c.MaxDiffBytes),
+		WithMaxFileLines(c.MaxFileLines),
+		WithMaxLineLength(c.MaxLineLength),
+	}
+	if c.DetectReindentedMoves {
+		opts = append(opts, WithReindentedMoves())
+	}
+
+	return opts
 }
 
 type yamlConfiguration struct {
@@ -44,8 +156,22 @@ type yamlConfiguration struct {
 		Formatter            string `yaml:"formatter"`
 		FetchPullRequestInfo bool   `yaml:"fetchPullRequestInfo"`
 		NoGH                 bool   `yaml:"noGH"`
+		ExcludeGenerated     bool   `yaml:"excludeGenerated"`
+		IgnoreMoved          bool   `yaml:"ignoreMoved"`
+		ReindentedMoves      bool   `yaml:"detectReindentedMoves"`
+		MaxDiffBytes         int64  `yaml:"maxDiffBytes"`
+		MaxFileLines         int    `yaml:"maxFileLines"`
+		MaxLineLength        int    `yaml:"maxLineLength"`
+		ImportFileThreshold  int64  `yaml:"importFileThreshold"`
+		ApplySuppressions    bool   `yaml:"applySuppressions"`
 		Checkers             map[string]struct {
-			Command string `yaml:"command"`
+			Command      string   `yaml:"command"`
+			Differential bool     `yaml:"differential"`
+			Description  string   `yaml:"description"`
+			Doc          string   `yaml:"doc"`
+			Paths        []string `yaml:"paths"`
+			Enabled      *bool    `yaml:"enabled"`
+			Rules        []Rule   `yaml:"rules"`
 		} `yaml:"checkers"`
 	} `yaml:"manifest"`
 }
@@ -73,6 +199,38 @@ func ParseConfig(r io.Reader, c *Configuration, formatters map[string]Formatter)
 		c.FetchPullInfo = true
 	}
 
+	if yamlConfig.Manifest.ExcludeGenerated {
+		c.ExcludeGenerated = true
+	}
+
+	if yamlConfig.Manifest.IgnoreMoved {
+		c.IgnoreMoved = true
+	}
+
+	if yamlConfig.Manifest.ReindentedMoves {
+		c.DetectReindentedMoves = true
+	}
+
+	if yamlConfig.Manifest.ApplySuppressions {
+		c.ApplySuppressions = true
+	}
+
+	if yamlConfig.Manifest.MaxDiffBytes > 0 {
+		c.MaxDiffBytes = yamlConfig.Manifest.MaxDiffBytes
+	}
+
+	if yamlConfig.Manifest.MaxFileLines > 0 {
+		c.MaxFileLines = yamlConfig.Manifest.MaxFileLines
+	}
+
+	if yamlConfig.Manifest.MaxLineLength > 0 {
+		c.MaxLineLength = yamlConfig.Manifest.MaxLineLength
+	}
+
+	if yamlConfig.Manifest.ImportFileThreshold > 0 {
+		c.ImportFileThreshold = yamlConfig.Manifest.ImportFileThreshold
+	}
+
 	if yamlConfig.Manifest.Formatter != "" {
 		formatter, ok := formatters[yamlConfig.Manifest.Formatter]
 		if !ok {
@@ -82,11 +240,179 @@ func ParseConfig(r io.Reader, c *Configuration, formatters map[string]Formatter)
 	}
 
 	if c.Checkers == nil {
-		c.Checkers = make(map[string]string, len(yamlConfig.Manifest.Checkers))
+		c.Checkers = make(map[string]Checker, len(yamlConfig.Manifest.Checkers))
 	}
 	for name, checker := range yamlConfig.Manifest.Checkers {
-		c.Checkers[name] = checker.Command
+		for _, glob := range checker.Paths {
+			if _, err := pathmatch.Compile(glob); err != nil {
+				return fmt.Errorf("invalid paths for checker '%s': %w", name, err)
+			}
+		}
+
+		c.Checkers[name] = Checker{
+			Command:      checker.Command,
+			Differential: checker.Differential,
+			Description:  checker.Description,
+			Doc:          checker.Doc,
+			Paths:        checker.Paths,
+			Disabled:     checker.Enabled != nil && !*checker.Enabled,
+			Rules:        checker.Rules,
+		}
+	}
+
+	return nil
+}
+
+// SelectCheckers limits the configured checkers to the ones matching any of
+// the only patterns, enabling them if they're disabled, then removes the ones
+// matching any of the skip patterns.
+// Patterns can be checker names or globs like `rails_*`, and can be comma
+// separated. It returns an error listing the valid checkers when a pattern
+// doesn't match any checker.
+func (c *Configuration) SelectCheckers(only []string, skip []string) error {
+	only = splitPatterns(only)
+	skip = splitPatterns(skip)
+
+	for _, pattern := range append(append([]string{}, only...), skip...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid checker pattern '%s': %w", pattern, err)
+		}
+		if !c.matchesAnyChecker(pattern) {
+			return fmt.Errorf("unknown checker '%s', valid checkers are: %s", pattern, strings.Join(c.checkerNames(), ", "))
+		}
+	}
+
+	for name, checker := range c.Checkers {
+		if len(only) > 0 && !matchesAny(only, name) {
+			delete(c.Checkers, name)
+		} else if matchesAny(skip, name) {
+			delete(c.Checkers, name)
+		} else if len(only) > 0 {
+			// Selecting a disabled checker by name runs it
+			checker.Disabled = false
+			c.Checkers[name] = checker
+		}
 	}
 
 	return nil
 }
+
+func (c *Configuration) matchesAnyChecker(pattern string) bool {
+	for name := range c.Checkers {
+		if matchesAny([]string{pattern}, name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkerNames returns the sorted names of the configured checkers.
+func (c *Configuration) checkerNames() []string {
+	names := make([]string, 0, len(c.Checkers))
+	for name := range c.Checkers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+func matchesAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// splitPatterns splits comma separated patterns, like `--only a,b`.
+func splitPatterns(values []string) []string {
+	patterns := make([]string, 0, len(values))
+	for _, value := range values {
+		for _, pattern := range strings.Split(value, ",") {
+			if pattern = strings.TrimSpace(pattern); pattern != "" {
+				patterns = append(patterns, pattern)
+			}
+		}
+	}
+
+	return patterns
+}
+
+// AddCheckerToConfig adds a checker with the given command to the YAML
+// configuration, keeping the existing comments and formatting where possible.
+// It returns an error if the checker is already configured.
+func AddCheckerToConfig(content []byte, name string, command string) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, fmt.Errorf("could not parse configuration file: %w", err)
+	}
+
+	if doc.Kind == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("configuration file must be a mapping")
+	}
+
+	manifestNode, err := mappingValue(doc.Content[0], "manifest")
+	if err != nil {
+		return nil, err
+	}
+	checkersNode, err := mappingValue(manifestNode, "checkers")
+	if err != nil {
+		return nil, err
+	}
+
+	for i := 0; i < len(checkersNode.Content); i += 2 {
+		if checkersNode.Content[i].Value == name {
+			return nil, fmt.Errorf("checker '%s' is already configured", name)
+		}
+	}
+
+	checkersNode.Content = append(checkersNode.Content,
+		&yaml.Node{Kind: yaml.ScalarNode, Value: name},
+		&yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{
+			{Kind: yaml.ScalarNode, Value: "command"},
+			{Kind: yaml.ScalarNode, Value: command, Style: yaml.SingleQuotedStyle},
+		}},
+	)
+
+	var out bytes.Buffer
+	encoder := yaml.NewEncoder(&out)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return nil, fmt.Errorf("could not write configuration file: %w", err)
+	}
+
+	return out.Bytes(), nil
+}
+
+// mappingValue returns the mapping stored under key in the given mapping,
+// creating it if it's missing or empty.
+func mappingValue(mapping *yaml.Node, key string) (*yaml.Node, error) {
+	for i := 0; i < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value != key {
+			continue
+		}
+
+		value := mapping.Content[i+1]
+		// A key without a value, like `checkers:`, is null
+		if value.Kind == yaml.ScalarNode && value.Tag == "!!null" {
+			*value = yaml.Node{Kind: yaml.MappingNode}
+		}
+		if value.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("'%s' in the configuration file must be a mapping", key)
+		}
+
+		return value, nil
+	}
+
+	value := &yaml.Node{Kind: yaml.MappingNode}
+	mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, value)
+
+	return value, nil
+}
@@ -22,7 +22,135 @@ func TestConfig(t *testing.T) {
 
 	require.Equal(t, 2, config.Concurrency)
 	require.NotNil(t, config.Formatter)
+	require.True(t, config.ExcludeGenerated)
 	require.Len(t, config.Checkers, 1, "expected 1 plugin to be configured")
 	railsJobCheck := config.Checkers["rails_job_perform"]
-	require.Equal(t, "manifest checker rails_job_perform", railsJobCheck)
+	require.Equal(t, "manifest checker rails_job_perform", railsJobCheck.Command)
+}
+
+func TestConfig_CheckerMetadata(t *testing.T) {
+	content := `manifest:
+  checkers:
+    no_todos:
+      command: script/no-todos
+      description: Disallows TODO comments
+      doc: Long-form documentation
+      paths: ["app/**/*.rb"]
+      enabled: false
+      rules:
+        - id: todo
+          description: A TODO comment was added
+`
+
+	config := &Configuration{}
+	err := ParseConfig(strings.NewReader(content), config, map[string]Formatter{"pretty": noopFormatter{}})
+	require.NoError(t, err)
+
+	require.Equal(t, Checker{
+		Command:     "script/no-todos",
+		Description: "Disallows TODO comments",
+		Doc:         "Long-form documentation",
+		Paths:       []string{"app/**/*.rb"},
+		Disabled:    true,
+		Rules:       []Rule{{ID: "todo", Description: "A TODO comment was added"}},
+	}, config.Checkers["no_todos"])
+}
+
+func TestConfig_InvalidPaths(t *testing.T) {
+	content := `manifest:
+  checkers:
+    no_todos:
+      command: script/no-todos
+      paths: ["[z-a].rb"]
+`
+
+	err := ParseConfig(strings.NewReader(content), &Configuration{}, map[string]Formatter{})
+	require.ErrorContains(t, err, `invalid paths for checker 'no_todos': invalid pattern "[z-a].rb"`)
+}
+
+func TestChecker_AppliesTo(t *testing.T) {
+	diff := Diff{Files: map[string]File{
+		"app/models/user.rb": {Name: "app/models/user.rb", OldName: "app/models/user.rb"},
+		"README.md":          {Name: "README.md", OldName: "README.md"},
+	}}
+
+	cases := []struct {
+		paths []string
+		want  bool
+	}{
+		{paths: nil, want: true},
+		{paths: []string{"app/**/*.rb"}, want: true},
+		{paths: []string{"app/"}, want: true},
+		{paths: []string{"app"}, want: true},
+		{paths: []string{"models/"}, want: true},
+		{paths: []string{"app/*"}, want: false},
+		{paths: []string{"lib/"}, want: false},
+		{paths: []string{"*.go"}, want: false},
+	}
+
+	for _, c := range cases {
+		require.Equal(t, c.want, Checker{Paths: c.paths}.AppliesTo(diff), "paths %v", c.paths)
+	}
+}
+
+func TestAddCheckerToConfig(t *testing.T) {
+	out, err := AddCheckerToConfig([]byte(testConfig), "no_todos", "script/no-todos")
+	require.NoError(t, err)
+
+	config := &Configuration{}
+	err = ParseConfig(strings.NewReader(string(out)), config, map[string]Formatter{"pretty": noopFormatter{}})
+	require.NoError(t, err)
+
+	require.Equal(t, map[string]Checker{
+		"rails_job_perform": {Command: "manifest checker rails_job_perform"},
+		"no_todos":          {Command: "script/no-todos"},
+	}, config.Checkers)
+	require.True(t, config.ExcludeGenerated)
+
+	_, err = AddCheckerToConfig(out, "no_todos", "script/no-todos")
+	require.ErrorContains(t, err, "already configured")
+}
+
+func TestAddCheckerToConfig_KeepsComments(t *testing.T) {
+	content := "# Our config\nmanifest:\n  concurrency: 2 # keep\n  checkers:\n"
+
+	out, err := AddCheckerToConfig([]byte(content), "no_todos", "script/no-todos")
+	require.NoError(t, err)
+
+	require.Equal(t, "# Our config\nmanifest:\n  concurrency: 2 # keep\n  checkers:\n    no_todos:\n      command: 'script/no-todos'\n", string(out))
+
+	out, err = AddCheckerToConfig(nil, "no_todos", "script/no-todos")
+	require.NoError(t, err)
+	require.Equal(t, "manifest:\n  checkers:\n    no_todos:\n      command: 'script/no-todos'\n", string(out))
+}
+
+func TestSelectCheckers(t *testing.T) {
+	newConfig := func() *Configuration {
+		return &Configuration{Checkers: map[string]Checker{
+			"rails_jobs":   {Command: "a"},
+			"rails_routes": {Command: "b"},
+			"pull-body":    {Command: "c"},
+		}}
+	}
+
+	config := newConfig()
+	require.NoError(t, config.SelectCheckers([]string{"rails_*"}, []string{"rails_routes"}))
+	require.Equal(t, map[string]Checker{"rails_jobs": {Command: "a"}}, config.Checkers)
+
+	config = newConfig()
+	require.NoError(t, config.SelectCheckers([]string{"pull-body,rails_jobs"}, nil))
+	require.Len(t, config.Checkers, 2)
+
+	config = newConfig()
+	require.NoError(t, config.SelectCheckers(nil, []string{"pull-body"}))
+	require.Len(t, config.Checkers, 2)
+
+	config = newConfig()
+	config.Checkers["pull-body"] = Checker{Command: "c", Disabled: true}
+	require.NoError(t, config.SelectCheckers([]string{"pull-body"}, nil))
+	require.Equal(t, map[string]Checker{"pull-body": {Command: "c"}}, config.Checkers)
+
+	config = newConfig()
+	err := config.SelectCheckers([]string{"rails_job"}, nil)
+	require.EqualError(t, err, "unknown checker 'rails_job', valid checkers are: pull-body, rails_jobs, rails_routes")
 }
@@ -0,0 +1,118 @@
+package manifest
+
+import (
+	"fmt"
+)
+
+// lineShiftTolerance is how many lines a finding can move between the base and
+// head and still be considered the same finding, since unrelated changes in
+// the file can shift where a checker reports it.
+const lineShiftTolerance = 3
+
+// differentialBase is the base of the change that differential checkers are
+// also run against.
+type differentialBase struct {
+	dir string
+	imp *Import
+}
+
+// SetDifferentialBase sets the import and working directory of the base of the
+// change. Differential checkers are run against both the base and the head,
+// and only the findings introduced by the change are reported.
+func (i *Check) SetDifferentialBase(dir string, base *Import) {
+	i.base = &differentialBase{dir: dir, imp: base}
+}
+
+// findingKey identifies a finding independent of the line it's reported on.
+type findingKey struct {
+	file     string
+	text     string
+	severity Severity
+}
+
+// finding is a base comment mapped to where it would be in the head.
+type finding struct {
+	comment Comment
+	line    uint
+	matched bool
+}
+
+// differentialComments compares the comments reported against the head and
+// the base of a change. It returns the head comments that aren't present in
+// the base, and the base comments that were fixed by the change. Base comments
+// are mapped to the head using the diff before comparing.
+func differentialComments(diff Diff, head []Comment, base []Comment) ([]Comment, []Comment) {
+	findings := make([]*finding, 0, len(base))
+	byKey := make(map[findingKey][]*finding)
+	for _, comment := range base {
+		f := &finding{comment: comment, line: comment.Line}
+		findings = append(findings, f)
+
+		name := comment.File
+		if file, ok := diff.Files[comment.File]; ok {
+			name = file.Name
+
+			if comment.Line != 0 {
+				// The line the finding was reported on was deleted by the
+				// change, so it can't be the same as any finding in the head.
+				mapped, ok := file.NewLineNo(comment.Line)
+				if !ok {
+					continue
+				}
+				f.line = mapped
+			}
+		}
+
+		key := findingKey{file: name, text: comment.Text, severity: comment.Severity}
+		byKey[key] = append(byKey[key], f)
+	}
+
+	introduced := make([]Comment, 0)
+	for _, comment := range head {
+		key := findingKey{file: comment.File, text: comment.Text, severity: comment.Severity}
+
+		var closest *finding
+		for _, f := range byKey[key] {
+			if f.matched || lineDistance(f.line, comment.Line) > lineShiftTolerance {
+				continue
+			}
+			if closest == nil || lineDistance(f.line, comment.Line) < lineDistance(closest.line, comment.Line) {
+				closest = f
+			}
+		}
+
+		if closest == nil {
+			introduced = append(introduced, comment)
+			continue
+		}
+		closest.matched = true
+	}
+
+	fixed := make([]Comment, 0)
+	for _, f := range findings {
+		if !f.matched {
+			fixed = append(fixed, fixedComment(f.comment))
+		}
+	}
+
+	return introduced, fixed
+}
+
+// fixedComment returns the top-level comment reporting that a finding in the
+// base was fixed.
+func fixedComment(comment Comment) Comment {
+	text := fmt.Sprintf("Fixed: %s", comment.Text)
+	if comment.File != "" {
+		text = fmt.Sprintf("Fixed in %s:%d: %s", comment.File, comment.Line, comment.Text)
+	}
+
+	return Comment{Text: text, Severity: SeverityInfo}
+}
+
+func lineDistance(a uint, b uint) uint {
+	if a > b {
+		return a - b
+	}
+
+	return b - a
+}
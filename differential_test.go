@@ -0,0 +1,90 @@
+package manifest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var differentialDiff = `
+diff --git a/a.txt b/a.txt
+index abc1234..def5678 100644
+--- a/a.txt
++++ b/a.txt
+@@ -1,4 +1,5 @@
++zero
+ one
+ TODO old
+ three
+-TODO gone
++TODO new
+diff --git a/old.txt b/new.txt
+similarity index 90%
+rename from old.txt
+rename to new.txt
+`
+
+func TestDifferentialComments(t *testing.T) {
+	diff, err := NewDiff(strings.NewReader(differentialDiff))
+	require.NoError(t, err)
+
+	base := []Comment{
+		{File: "a.txt", Line: 2, Text: "TODO found", Severity: SeverityWarn},
+		{File: "a.txt", Line: 4, Text: "TODO found", Severity: SeverityWarn},
+		{File: "old.txt", Line: 10, Text: "too long", Severity: SeverityError},
+		{Text: "missing license", Severity: SeverityWarn},
+	}
+	head := []Comment{
+		{File: "a.txt", Line: 3, Text: "TODO found", Severity: SeverityWarn},
+		{File: "a.txt", Line: 5, Text: "TODO found", Severity: SeverityWarn},
+		// The checker reports the finding a couple lines off
+		{File: "new.txt", Line: 12, Text: "too long", Severity: SeverityError},
+		{Text: "missing license", Severity: SeverityWarn},
+		{Text: "missing changelog", Severity: SeverityWarn},
+	}
+
+	introduced, fixed := differentialComments(diff, head, base)
+
+	require.Equal(t, []Comment{
+		{File: "a.txt", Line: 5, Text: "TODO found", Severity: SeverityWarn},
+		{Text: "missing changelog", Severity: SeverityWarn},
+	}, introduced)
+	require.Equal(t, []Comment{
+		{Text: "Fixed in a.txt:4: TODO found", Severity: SeverityInfo},
+	}, fixed)
+}
+
+func TestPerform_Differential(t *testing.T) {
+	formatter := &recordingFormatter{}
+	config := &Configuration{
+		Concurrency: 2,
+		Formatter:   formatter,
+		ShowFixed:   true,
+		Checkers: map[string]Checker{
+			// Reports a finding for every file in the import, like a linter
+			// that checks whole files.
+			"lint": {
+				Command:      `grep -q '"new":\[\]' && echo '{"comments":[{"text":"old","severity":"Error"}]}' || echo '{"comments":[{"text":"old","severity":"Error"},{"text":"new","severity":"Error"}]}'`,
+				Differential: true,
+			},
+			"plain": {Command: `echo '{"comments":[{"text":"plain","severity":"Warn"}]}'`},
+		},
+	}
+
+	check, err := NewCheck(config, strings.NewReader(newFile))
+	require.NoError(t, err)
+
+	check.SetDifferentialBase(t.TempDir(), &Import{Diff: newDiff(nil, diffOptions{})})
+
+	require.ErrorIs(t, check.Perform(), ErrCheckReportedError)
+	require.Len(t, formatter.results, 2)
+
+	texts := make([]string, 0)
+	for _, result := range formatter.results {
+		for _, comment := range result.Comments {
+			texts = append(texts, comment.Text)
+		}
+	}
+	require.ElementsMatch(t, []string{"new", "plain"}, texts)
+}
@@ -0,0 +1,378 @@
+// Package annotateformat prints the diff being checked with the comments of
+// the checkers rendered inline, under the lines they refer to, like a code
+// review in the terminal.
+package annotateformat
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/fatih/color"
+
+	"github.com/blakewilliams/manifest"
+)
+
+var (
+	fileColor    = color.New(color.Bold)
+	hunkColor    = color.New(color.FgCyan)
+	addColor     = color.New(color.FgGreen)
+	deleteColor  = color.New(color.FgRed)
+	gutterColor  = color.New(color.Faint)
+	errorColor   = color.New(color.FgRed, color.Bold)
+	warnColor    = color.New(color.FgYellow, color.Bold)
+	infoColor    = color.New(color.FgBlue, color.Bold)
+	neutralColor = color.New(color.Faint)
+)
+
+// annotation is a comment along with the checker that left it.
+type annotation struct {
+	source  string
+	comment manifest.Comment
+}
+
+// Formatter collects the results of every checker, then prints the annotated
+// diff once all of the checkers have finished.
+type Formatter struct {
+	out         io.Writer
+	mu          sync.Mutex
+	annotations []annotation
+}
+
+var _ manifest.FormatterWithHooks = (*Formatter)(nil)
+
+func New(out io.Writer) *Formatter {
+	return &Formatter{out: out}
+}
+
+func (f *Formatter) BeforeAll(i *manifest.Import) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.annotations = nil
+
+	return nil
+}
+
+func (f *Formatter) Format(source string, i *manifest.Import, r manifest.Result) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, comment := range r.Comments {
+		f.annotations = append(f.annotations, annotation{source: source, comment: comment})
+	}
+
+	return nil
+}
+
+// AfterAll prints the top-level comments followed by the diff of every file,
+// with the comments on each line printed under it.
+func (f *Formatter) AfterAll(i *manifest.Import) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// Checkers run concurrently, so their results arrive in any order
+	sort.SliceStable(f.annotations, func(a, b int) bool {
+		return f.annotations[a].source < f.annotations[b].source
+	})
+
+	fmt.Fprintf(f.out, "Checking %s\n\n", i.Diff.Stats)
+
+	byFile := make(map[string][]annotation)
+	topLevel := make([]annotation, 0)
+	for _, a := range f.annotations {
+		if a.comment.File == "" {
+			topLevel = append(topLevel, a)
+			continue
+		}
+
+		byFile[a.comment.File] = append(byFile[a.comment.File], a)
+	}
+
+	for _, a := range topLevel {
+		writeAnnotation(f.out, "", a)
+	}
+	if len(topLevel) > 0 {
+		fmt.Fprintln(f.out)
+	}
+
+	// Diff.Files is keyed by the old names of files, but comments use the
+	// new ones
+	files := make(map[string]manifest.File, len(i.Diff.Files))
+	for _, file := range i.Diff.Files {
+		files[newName(file)] = file
+	}
+
+	names := make([]string, 0, len(files)+len(byFile))
+	for name := range files {
+		names = append(names, name)
+	}
+	for name := range byFile {
+		if _, ok := files[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		writeFile(f.out, name, files[name], byFile[name])
+	}
+
+	return nil
+}
+
+// newName returns the name the file has after the change, or its old name if
+// it was deleted.
+func newName(file manifest.File) string {
+	if file.Name == "" {
+		return file.OldName
+	}
+
+	return file.Name
+}
+
+// diffLine is a line of a file's diff, in the order it's printed.
+type diffLine struct {
+	oldLineNo uint
+	newLineNo uint
+	deleted   bool
+	// context is true for unchanged lines, which have both line numbers
+	context bool
+	content string
+	// header is set on the first line of every hunk
+	header string
+}
+
+// fileLines returns the lines of the file's hunks with their context lines,
+// or the changed lines alone when the hunks aren't known, like for saved
+// imports or truncated files.
+func fileLines(file manifest.File) []diffLine {
+	if len(file.Fragments) == 0 {
+		return diffLines(file)
+	}
+	for _, fragment := range file.Fragments {
+		if fragment.Lines == nil {
+			return diffLines(file)
+		}
+	}
+
+	lines := make([]diffLine, 0)
+	for _, fragment := range file.Fragments {
+		header := fmt.Sprintf("@@ -%s +%s @@", hunkRange(fragment.OldPosition, fragment.OldLines), hunkRange(fragment.NewPosition, fragment.NewLines))
+		if fragment.Comment != "" {
+			header += " " + fragment.Comment
+		}
+
+		for n, fragmentLine := range fragment.Lines {
+			line := diffLine{
+				oldLineNo: fragmentLine.OldLineNo,
+				newLineNo: fragmentLine.NewLineNo,
+				deleted:   fragmentLine.NewLineNo == 0,
+				context:   fragmentLine.OldLineNo != 0 && fragmentLine.NewLineNo != 0,
+				content:   fragmentLine.Content,
+			}
+			if n == 0 {
+				line.header = header
+			}
+
+			lines = append(lines, line)
+		}
+	}
+
+	return lines
+}
+
+// hunkRange formats the range of a hunk header like git, omitting the count
+// when it's one.
+func hunkRange(position uint, count uint) string {
+	if count == 1 {
+		return fmt.Sprint(position)
+	}
+
+	return fmt.Sprintf("%d,%d", position, count)
+}
+
+// diffLines interleaves the deleted and added lines of the file, deletions
+// first, starting a new hunk whenever unchanged lines were skipped.
+func diffLines(file manifest.File) []diffLine {
+	lines := make([]diffLine, 0, len(file.Left)+len(file.Right))
+
+	// offset is the difference between the new and old line numbers of the
+	// unchanged lines at the current position
+	offset := 0
+	next := uint(0)
+	l, r := 0, 0
+	for l < len(file.Left) || r < len(file.Right) {
+		var line diffLine
+		var position uint
+
+		if l < len(file.Left) && (r >= len(file.Right) || int(file.Left[l].LineNo)+offset <= int(file.Right[r].LineNo)) {
+			position = uint(int(file.Left[l].LineNo) + offset)
+			line = diffLine{oldLineNo: file.Left[l].LineNo, deleted: true, content: file.Left[l].Content}
+			offset--
+			l++
+		} else {
+			position = file.Right[r].LineNo
+			line = diffLine{newLineNo: file.Right[r].LineNo, content: file.Right[r].Content}
+			offset++
+			r++
+		}
+
+		if len(lines) == 0 || position != next {
+			line.header = fmt.Sprintf("@@ %s @@", hunkPosition(line))
+		}
+		next = position
+		if !line.deleted {
+			next++
+		}
+
+		lines = append(lines, line)
+	}
+
+	return lines
+}
+
+func writeFile(out io.Writer, name string, file manifest.File, annotations []annotation) {
+	header := name
+	switch file.Operation {
+	case manifest.DiffOperationRename:
+		header = fmt.Sprintf("%s (renamed from %s)", name, file.OldName)
+	case manifest.DiffOperationCopy:
+		header = fmt.Sprintf("%s (copied from %s)", name, file.OldName)
+	case manifest.DiffOperationNew:
+		header = name + " (new)"
+	case manifest.DiffOperationDelete:
+		header = name + " (deleted)"
+	}
+	fileColor.Fprintln(out, header)
+
+	lines := fileLines(file)
+
+	// Comments on lines that aren't part of the diff, or on a commit's diff,
+	// are printed with the file instead
+	inline := make(map[string][]annotation)
+	for _, a := range annotations {
+		at := lineKey(a.comment.Side == "LEFT", a.comment.Line)
+		if a.comment.Line != 0 && a.comment.Commit == "" && containsLine(lines, at) {
+			inline[at] = append(inline[at], a)
+			continue
+		}
+
+		label := ""
+		if a.comment.Line != 0 {
+			label = fmt.Sprintf("line %d", a.comment.Line)
+		}
+		writeAnnotation(out, label, a)
+	}
+
+	for _, line := range lines {
+		if line.header != "" {
+			hunkColor.Fprintln(out, line.header)
+		}
+
+		content := strings.TrimSuffix(line.content, "\n")
+		switch {
+		case line.context:
+			gutterColor.Fprintf(out, "%5d %5d ", line.oldLineNo, line.newLineNo)
+			fmt.Fprintf(out, " %s\n", content)
+		case line.deleted:
+			gutterColor.Fprintf(out, "%5d       ", line.oldLineNo)
+			deleteColor.Fprintf(out, "-%s\n", content)
+		default:
+			gutterColor.Fprintf(out, "      %5d ", line.newLineNo)
+			addColor.Fprintf(out, "+%s\n", content)
+		}
+
+		for _, k := range keys(line) {
+			for _, a := range inline[k] {
+				writeAnnotation(out, "", a)
+			}
+		}
+	}
+
+	if file.Truncated {
+		neutralColor.Fprintln(out, "(diff truncated)")
+	}
+
+	fmt.Fprintln(out)
+}
+
+func hunkPosition(line diffLine) string {
+	if line.deleted {
+		return fmt.Sprintf("-%d", line.oldLineNo)
+	}
+
+	return fmt.Sprintf("+%d", line.newLineNo)
+}
+
+func lineKey(left bool, lineNo uint) string {
+	if left {
+		return fmt.Sprintf("L%d", lineNo)
+	}
+
+	return fmt.Sprintf("R%d", lineNo)
+}
+
+// keys returns the keys of the sides of the diff the line is on. Context lines
+// are on both sides.
+func keys(line diffLine) []string {
+	switch {
+	case line.context:
+		return []string{lineKey(true, line.oldLineNo), lineKey(false, line.newLineNo)}
+	case line.deleted:
+		return []string{lineKey(true, line.oldLineNo)}
+	default:
+		return []string{lineKey(false, line.newLineNo)}
+	}
+}
+
+func containsLine(lines []diffLine, want string) bool {
+	for _, line := range lines {
+		if slices.Contains(keys(line), want) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// writeAnnotation prints the comment in a box under the line, or file, it
+// refers to.
+func writeAnnotation(out io.Writer, label string, a annotation) {
+	severityColor, severity := severityStyle(a.comment.Severity)
+
+	title := fmt.Sprintf("%s: %s", severity, a.source)
+	if a.comment.Rule != "" {
+		title += fmt.Sprintf(" [%s]", a.comment.Rule)
+	}
+	if a.comment.Commit != "" {
+		commit := a.comment.Commit
+		if len(commit) > 7 {
+			commit = commit[:7]
+		}
+		title += " in commit " + commit
+	}
+	if label != "" {
+		title += " on " + label
+	}
+
+	bar := severityColor.Sprint("│")
+	fmt.Fprintf(out, "            %s %s\n", bar, severityColor.Sprint(title))
+	for _, line := range strings.Split(strings.TrimRight(a.comment.Text, "\n"), "\n") {
+		fmt.Fprintf(out, "            %s %s\n", bar, line)
+	}
+}
+
+func severityStyle(severity manifest.Severity) (*color.Color, string) {
+	switch severity {
+	case manifest.SeverityError:
+		return errorColor, "Error"
+	case manifest.SeverityWarn:
+		return warnColor, "Warning"
+	default:
+		return infoColor, "Info"
+	}
+}
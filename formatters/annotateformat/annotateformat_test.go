@@ -0,0 +1,136 @@
+package annotateformat
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/blakewilliams/manifest"
+	"github.com/stretchr/testify/require"
+)
+
+const changedFile = `diff --git a/app.rb b/app.rb
+index 1a2b3c4..5d6e7f8 100644
+--- a/app.rb
++++ b/app.rb
+@@ -1,4 +1,4 @@
+ class App
+-  def old
++  def new
+   end
+ end
+@@ -10,2 +10,3 @@ class App
+ # footer
++# TODO
+ # end
+`
+
+func TestFormatter(t *testing.T) {
+	diff, err := manifest.NewDiff(strings.NewReader(changedFile))
+	require.NoError(t, err)
+	i := &manifest.Import{Diff: diff}
+
+	var out bytes.Buffer
+	f := New(&out)
+	require.NoError(t, f.BeforeAll(i))
+	require.NoError(t, f.Format("todos", i, manifest.Result{Comments: []manifest.Comment{
+		{File: "app.rb", Line: 11, Side: "RIGHT", Text: "Don't add TODOs", Severity: manifest.SeverityError},
+		{File: "app.rb", Line: 7, Text: "Not part of the diff", Severity: manifest.SeverityInfo},
+	}}))
+	require.NoError(t, f.Format("naming", i, manifest.Result{Comments: []manifest.Comment{
+		{File: "app.rb", Line: 2, Side: "LEFT", Text: "Was this called anywhere?", Severity: manifest.SeverityWarn, Rule: "removed-method"},
+		{Text: "Please add a description", Severity: manifest.SeverityError},
+	}}))
+	require.NoError(t, f.AfterAll(i))
+
+	require.Equal(t, strings.Join([]string{
+		"Checking 1 file changed, 2 insertions(+), 1 deletion(-) in 2 hunks",
+		"",
+		"            │ Error: naming",
+		"            │ Please add a description",
+		"",
+		"app.rb",
+		"            │ Info: todos on line 7",
+		"            │ Not part of the diff",
+		"@@ -1,4 +1,4 @@",
+		"    1     1  class App",
+		"    2       -  def old",
+		"            │ Warning: naming [removed-method]",
+		"            │ Was this called anywhere?",
+		"          2 +  def new",
+		"    3     3    end",
+		"    4     4  end",
+		"@@ -10,2 +10,3 @@ class App",
+		"   10    10  # footer",
+		"         11 +# TODO",
+		"            │ Error: todos",
+		"            │ Don't add TODOs",
+		"   11    12  # end",
+		"",
+		"",
+	}, "\n"), out.String())
+}
+
+func TestFormatter_RenamedFile(t *testing.T) {
+	diff, err := manifest.NewDiff(strings.NewReader(`diff --git a/app/jobs/greeter_job.rb b/app/jobs/welcome_job.rb
+similarity index 80%
+rename from app/jobs/greeter_job.rb
+rename to app/jobs/welcome_job.rb
+index 1a2b3c4..5d6e7f8 100644
+--- a/app/jobs/greeter_job.rb
++++ b/app/jobs/welcome_job.rb
+@@ -1,3 +1,3 @@
+-class GreeterJob
++class WelcomeJob
+   def perform
+   end
+`))
+	require.NoError(t, err)
+	i := &manifest.Import{Diff: diff}
+
+	var out bytes.Buffer
+	f := New(&out)
+	require.NoError(t, f.BeforeAll(i))
+	require.NoError(t, f.Format("naming", i, manifest.Result{Comments: []manifest.Comment{
+		{File: "app/jobs/welcome_job.rb", Line: 1, Side: "RIGHT", Text: "Jobs end in Job", Severity: manifest.SeverityInfo},
+	}}))
+	require.NoError(t, f.AfterAll(i))
+
+	require.Equal(t, strings.Join([]string{
+		"Checking 1 file changed, 1 insertion(+), 1 deletion(-) in 1 hunk",
+		"",
+		"app/jobs/welcome_job.rb (renamed from app/jobs/greeter_job.rb)",
+		"@@ -1,3 +1,3 @@",
+		"    1       -class GreeterJob",
+		"          1 +class WelcomeJob",
+		"            │ Info: naming",
+		"            │ Jobs end in Job",
+		"    2     2    def perform",
+		"    3     3    end",
+		"",
+		"",
+	}, "\n"), out.String())
+}
+
+func TestDiffLines(t *testing.T) {
+	file := manifest.File{
+		Left:  []manifest.Line{{LineNo: 2, Content: "b"}, {LineNo: 3, Content: "c"}, {LineNo: 9, Content: "i"}},
+		Right: []manifest.Line{{LineNo: 1, Content: "z"}, {LineNo: 3, Content: "B"}, {LineNo: 9, Content: "h"}},
+	}
+
+	// Without hunks, like in saved imports, they're rebuilt from the changed
+	// lines
+	lines := fileLines(file)
+
+	got := make([]string, len(lines))
+	for n, line := range lines {
+		got[n] = keys(line)[0]
+		if line.header != "" {
+			got[n] = "@" + got[n]
+		}
+	}
+
+	// z is added before b, shifting b and c down a line, b and c are replaced
+	// with B, then i is replaced with h
+	require.Equal(t, []string{"@R1", "@L2", "L3", "R3", "@L9", "R9"}, got)
+}
@@ -124,6 +124,10 @@ func (f *Formatter) Format(source string, i *manifest.Import, r manifest.Result)
 				message.WriteString("\n")
 			}
 
+			if comment.MentionOwners {
+				message.WriteString(ownerMentions(i, comment))
+			}
+
 			message.WriteString(fmt.Sprintf(footer, source))
 
 			c := github.NewFileComment{
@@ -138,12 +142,20 @@ func (f *Formatter) Format(source string, i *manifest.Import, r manifest.Result)
 				return err
 			}
 		} else {
+			if comment.Commit != "" {
+				message.WriteString(fmt.Sprintf("> Commit %s\n>\n", comment.Commit))
+			}
+
 			for _, s := range strings.Split(comment.Text, "\n") {
 				message.WriteString("> ")
 				message.WriteString(s)
 				message.WriteString("\n")
 			}
 
+			if comment.MentionOwners {
+				message.WriteString(ownerMentions(i, comment))
+			}
+
 			message.WriteString("\n\n")
 			topLevelmessage.WriteString(message.String())
 		}
@@ -162,7 +174,39 @@ func (f *Formatter) Format(source string, i *manifest.Import, r manifest.Result)
 	return f.cliFormatter.Format(source, i, r)
 }
 
+// ownerMentions returns a line mentioning the owners of the commented file, or
+// the owners of the whole diff for top-level comments. Owners that can't be
+// mentioned, like email addresses, are skipped.
+func ownerMentions(i *manifest.Import, comment manifest.Comment) string {
+	owners := i.Diff.Owners
+	if comment.File != "" {
+		file, ok := i.Diff.FileByNewName(comment.File)
+		if !ok {
+			// Deleted files only have an old name
+			file = i.Diff.Files[comment.File]
+		}
+		owners = file.Owners
+	}
+
+	mentions := make([]string, 0, len(owners))
+	for _, owner := range owners {
+		if strings.HasPrefix(owner, "@") {
+			mentions = append(mentions, owner)
+		}
+	}
+
+	if len(mentions) == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("\ncc %s\n", strings.Join(mentions, " "))
+}
+
 func fingerprint(source string, comment manifest.Comment) string {
+	if comment.Commit != "" {
+		source = fmt.Sprintf("%s@%s", source, comment.Commit)
+	}
+
 	if comment.File == "" || comment.Line == 0 {
 		return fmt.Sprintf("manifest:%s", source)
 	}
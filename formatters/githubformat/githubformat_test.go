@@ -200,3 +200,49 @@ func TestFormat_ResolveComment(t *testing.T) {
 	client.AssertExpectations(t)
 	client.AssertCalled(t, "ResolveComment", mock.Anything)
 }
+
+func TestFormat_MentionOwners(t *testing.T) {
+	i := &manifest.Import{
+		Pull: &manifest.Pull{
+			Number: 1,
+		},
+		Diff: manifest.Diff{
+			Owners: []string{"@acme/payments", "@acme/web"},
+			Files: map[string]manifest.File{
+				"payments/charge.rb": {Name: "payments/charge.rb", OldName: "payments/charge.rb", Owners: []string{"@acme/payments", "payments@example.com"}},
+			},
+		},
+	}
+
+	result := manifest.Result{
+		Comments: []manifest.Comment{
+			{
+				Text:          "Payments changes need a note",
+				Severity:      manifest.SeverityWarn,
+				File:          "payments/charge.rb",
+				Line:          3,
+				Side:          "RIGHT",
+				MentionOwners: true,
+			},
+			{
+				Text:          "Top-level",
+				Severity:      manifest.SeverityWarn,
+				MentionOwners: true,
+			},
+		},
+	}
+
+	client := &fakeGitHubClient{}
+	client.On("FileComment", mock.MatchedBy(func(fc github.NewFileComment) bool {
+		return strings.Contains(fc.Text, "cc @acme/payments\n") && !strings.Contains(fc.Text, "payments@example.com")
+	})).Return(nil)
+	client.On("Comment", 1, mock.MatchedBy(func(comment string) bool {
+		return strings.Contains(comment, "cc @acme/payments @acme/web")
+	})).Return(nil)
+
+	formatter := New(io.Discard, client)
+	err := formatter.Format("test", i, result)
+	require.NoError(t, err)
+
+	client.AssertExpectations(t)
+}
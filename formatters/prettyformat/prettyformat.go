@@ -20,10 +20,26 @@ var warnColor = color.New(color.FgYellow, color.Bold)
 var errorColor = color.New(color.FgRed, color.Bold)
 var infoColor = color.New(color.FgBlue, color.Bold)
 
+var _ manifest.FormatterWithHooks = (*Formatter)(nil)
+
 func New(out io.Writer) *Formatter {
 	return &Formatter{out: out}
 }
 
+// BeforeAll prints a one-line summary of the diff being checked.
+func (s *Formatter) BeforeAll(i *manifest.Import) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	fmt.Fprintf(s.out, "Checking %s\n\n", i.Diff.Stats)
+
+	return nil
+}
+
+func (s *Formatter) AfterAll(i *manifest.Import) error {
+	return nil
+}
+
 func (s *Formatter) Format(source string, i *manifest.Import, r manifest.Result) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -47,6 +63,10 @@ func (s *Formatter) Format(source string, i *manifest.Import, r manifest.Result)
 			}
 		}
 
+		if comment.Commit != "" {
+			fmt.Fprintf(s.out, "commit %s\n", commitDescription(comment.Commit, i))
+		}
+
 		for _, line := range strings.Split(comment.Text, "\n") {
 			fmt.Fprintf(s.out, "  > %s\n", line)
 		}
@@ -56,3 +76,17 @@ func (s *Formatter) Format(source string, i *manifest.Import, r manifest.Result)
 
 	return nil
 }
+
+// commitDescription returns the short sha and subject of the given commit.
+func commitDescription(sha string, i *manifest.Import) string {
+	short := sha
+	if len(short) > 7 {
+		short = short[:7]
+	}
+
+	if i.Commit != nil && i.Commit.Sha == sha && i.Commit.Subject != "" {
+		return fmt.Sprintf("%s %s", short, i.Commit.Subject)
+	}
+
+	return short
+}
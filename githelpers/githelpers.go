@@ -3,9 +3,13 @@ package githelpers
 import (
 	"errors"
 	"fmt"
+	"io/fs"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"regexp"
 	"strings"
+	"time"
 )
 
 var ErrNoPushedBranch = errors.New("no pushed branch exists for current branch")
@@ -91,3 +95,337 @@ func gitPath() string {
 
 	return path
 }
+
+// Commit is a commit as reported by `git log`.
+type Commit struct {
+	Sha            string
+	AuthorName     string
+	AuthorEmail    string
+	AuthorDate     time.Time
+	CommitterName  string
+	CommitterEmail string
+	CommitterDate  time.Time
+	Subject        string
+	Body           string
+	Files          []string
+}
+
+// logFormat separates each commit with a record separator and each field
+// with a unit separator so that subjects and bodies can't be confused with
+// the file list that follows.
+const logFormat = "%x1e%H%x1f%an%x1f%ae%x1f%aI%x1f%cn%x1f%ce%x1f%cI%x1f%s%x1f%b%x1f"
+
+// Commits returns the commits in `base..head`, oldest first, along with the
+// files touched by each commit.
+func Commits(base string, head string) ([]Commit, error) {
+	cmd := exec.Command(gitPath(), "log", "--reverse", "--name-only", "--format="+logFormat, base+".."+head)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("could not get commits for %s..%s: %w", base, head, err)
+	}
+
+	return parseLog(string(output))
+}
+
+func parseLog(output string) ([]Commit, error) {
+	commits := make([]Commit, 0)
+
+	for _, record := range strings.Split(output, "\x1e") {
+		if strings.TrimSpace(record) == "" {
+			continue
+		}
+
+		fields := strings.Split(record, "\x1f")
+		if len(fields) != 10 {
+			return nil, fmt.Errorf("could not parse git log output: unexpected field count %d", len(fields))
+		}
+
+		authorDate, err := time.Parse(time.RFC3339, fields[3])
+		if err != nil {
+			return nil, fmt.Errorf("could not parse author date for %s: %w", fields[0], err)
+		}
+		committerDate, err := time.Parse(time.RFC3339, fields[6])
+		if err != nil {
+			return nil, fmt.Errorf("could not parse committer date for %s: %w", fields[0], err)
+		}
+
+		files := make([]string, 0)
+		for _, file := range strings.Split(fields[9], "\n") {
+			if file = strings.TrimSpace(file); file != "" {
+				files = append(files, file)
+			}
+		}
+
+		commits = append(commits, Commit{
+			Sha:            fields[0],
+			AuthorName:     fields[1],
+			AuthorEmail:    fields[2],
+			AuthorDate:     authorDate,
+			CommitterName:  fields[4],
+			CommitterEmail: fields[5],
+			CommitterDate:  committerDate,
+			Subject:        fields[7],
+			Body:           strings.TrimSpace(fields[8]),
+			Files:          files,
+		})
+	}
+
+	return commits, nil
+}
+
+// CommitDiff returns the diff introduced by the given commit. Merge commits
+// are diffed against their first parent.
+func CommitDiff(sha string) (string, error) {
+	cmd := exec.Command(gitPath(), "diff-tree", "-p", "-M", "-m", "--first-parent", "--root", "--no-commit-id", "--no-color", sha)
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("could not get diff for commit %s: %w", sha, err)
+	}
+
+	return string(output), nil
+}
+
+// TrackedFiles returns the files tracked by git in the given paths, relative
+// to the root of the repository. All tracked files are returned when no paths
+// are given.
+func TrackedFiles(paths ...string) ([]string, error) {
+	args := append([]string{"ls-files", "-z", "--full-name", "--"}, paths...)
+	output, err := exec.Command(gitPath(), args...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("could not list tracked files: %w", err)
+	}
+
+	files := make([]string, 0)
+	for _, file := range strings.Split(string(output), "\x00") {
+		if file != "" {
+			files = append(files, file)
+		}
+	}
+
+	return files, nil
+}
+
+// MergeBase returns the best common ancestor of the given refs.
+func MergeBase(a string, b string) (string, error) {
+	output, err := exec.Command(gitPath(), "merge-base", a, b).Output()
+	if err != nil {
+		return "", fmt.Errorf("could not find merge base of %s and %s: %w", a, b, err)
+	}
+
+	return strings.TrimSpace(string(output)), nil
+}
+
+// AddWorktree checks out ref into a new detached worktree at dir.
+func AddWorktree(dir string, ref string) error {
+	output, err := exec.Command(gitPath(), "worktree", "add", "--detach", "--quiet", dir, ref).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("could not create worktree for %s: %w: %s", ref, err, strings.TrimSpace(string(output)))
+	}
+
+	return nil
+}
+
+// RemoveWorktree removes a worktree created by AddWorktree.
+func RemoveWorktree(dir string) error {
+	output, err := exec.Command(gitPath(), "worktree", "remove", "--force", dir).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("could not remove worktree %s: %w: %s", dir, err, strings.TrimSpace(string(output)))
+	}
+
+	return nil
+}
+
+// WorkingTreeDiff returns the diff of the staged and unstaged changes in the
+// working tree.
+func WorkingTreeDiff() (string, error) {
+	return DiffFrom("HEAD")
+}
+
+// DiffFrom returns the diff between the given ref and the working tree.
+func DiffFrom(ref string) (string, error) {
+	output, err := exec.Command(gitPath(), "diff", "--no-color", ref).Output()
+	if err != nil {
+		return "", fmt.Errorf("could not get diff of working tree: %w", err)
+	}
+
+	return string(output), nil
+}
+
+// DiffWithUntrackedFrom returns the diff between the given ref and the working
+// tree like DiffFrom, including untracked files that aren't ignored as new
+// files. The files are added to a copy of the index so the real index isn't
+// modified.
+func DiffWithUntrackedFrom(ref string) (string, error) {
+	indexPath, err := exec.Command(gitPath(), "rev-parse", "--path-format=absolute", "--git-path", "index").Output()
+	if err != nil {
+		return "", fmt.Errorf("could not find the git index: %w", err)
+	}
+
+	dir, err := os.MkdirTemp("", "manifest-index-")
+	if err != nil {
+		return "", fmt.Errorf("could not create temporary index: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Without an index, like in a repository without commits, git creates one
+	tempIndex := filepath.Join(dir, "index")
+	index, err := os.ReadFile(strings.TrimSpace(string(indexPath)))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return "", fmt.Errorf("could not read the git index: %w", err)
+	}
+	if err == nil {
+		if err := os.WriteFile(tempIndex, index, 0o600); err != nil {
+			return "", fmt.Errorf("could not create temporary index: %w", err)
+		}
+	}
+	env := append(os.Environ(), "GIT_INDEX_FILE="+tempIndex)
+
+	add := exec.Command(gitPath(), "add", "--intent-to-add", "--", ":/")
+	add.Env = env
+	if output, err := add.CombinedOutput(); err != nil {
+		return "", fmt.Errorf("could not add untracked files to the diff: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+
+	diff := exec.Command(gitPath(), "diff", "--no-color", ref)
+	diff.Env = env
+	output, err := diff.Output()
+	if err != nil {
+		return "", fmt.Errorf("could not get diff of working tree: %w", err)
+	}
+
+	return string(output), nil
+}
+
+// IgnoredDirectories returns the untracked directories ignored by git in the
+// given directory, relative to it.
+func IgnoredDirectories(dir string) ([]string, error) {
+	cmd := exec.Command(gitPath(), "ls-files", "-z", "--others", "--ignored", "--exclude-standard", "--directory")
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("could not list ignored directories: %w", err)
+	}
+
+	dirs := make([]string, 0)
+	for _, path := range strings.Split(string(output), "\x00") {
+		if dir, ok := strings.CutSuffix(path, "/"); ok {
+			dirs = append(dirs, dir)
+		}
+	}
+
+	return dirs, nil
+}
+
+// StagedDiff returns the diff of the changes staged for commit.
+func StagedDiff() (string, error) {
+	output, err := exec.Command(gitPath(), "diff", "--no-color", "--cached").Output()
+	if err != nil {
+		return "", fmt.Errorf("could not get diff of staged changes: %w", err)
+	}
+
+	return string(output), nil
+}
+
+// RangeDiff returns the diff between the given commits.
+func RangeDiff(base string, head string) (string, error) {
+	output, err := exec.Command(gitPath(), "diff", "--no-color", base, head).Output()
+	if err != nil {
+		return "", fmt.Errorf("could not get diff of %s..%s: %w", base, head, err)
+	}
+
+	return string(output), nil
+}
+
+// EmptyTree is the sha of git's empty tree, used to diff root commits.
+const EmptyTree = "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
+
+// UnpushedBase returns the parent of the oldest commit reachable from sha that
+// isn't on any branch of the given remote, or EmptyTree when that commit is a
+// root commit. It returns sha when every commit is already on the remote.
+func UnpushedBase(remote string, sha string) (string, error) {
+	output, err := exec.Command(gitPath(), "rev-list", "--topo-order", "--reverse", sha, "--not", "--remotes="+remote).Output()
+	if err != nil {
+		return "", fmt.Errorf("could not list unpushed commits: %w", err)
+	}
+
+	oldest, _, _ := strings.Cut(strings.TrimSpace(string(output)), "\n")
+	if oldest == "" {
+		return sha, nil
+	}
+
+	output, err = exec.Command(gitPath(), "rev-list", "--parents", "-n", "1", oldest).Output()
+	if err != nil {
+		return "", fmt.Errorf("could not find parent of %s: %w", oldest, err)
+	}
+
+	fields := strings.Fields(string(output))
+	if len(fields) < 2 {
+		return EmptyTree, nil
+	}
+
+	return fields[1], nil
+}
+
+// HooksDir returns the directory git runs hooks from, which is core.hooksPath
+// when it's set.
+func HooksDir() (string, error) {
+	output, err := exec.Command(gitPath(), "rev-parse", "--path-format=absolute", "--git-path", "hooks").Output()
+	if err != nil {
+		return "", fmt.Errorf("could not find hooks directory: %w", err)
+	}
+
+	return strings.TrimSpace(string(output)), nil
+}
+
+// IsShallow returns true if the repository is a shallow clone.
+func IsShallow() (bool, error) {
+	output, err := exec.Command(gitPath(), "rev-parse", "--is-shallow-repository").Output()
+	if err != nil {
+		return false, fmt.Errorf("could not check if the repository is shallow: %w", err)
+	}
+
+	return strings.TrimSpace(string(output)) == "true", nil
+}
+
+// FileAtRevision returns the contents of the file at path in the given
+// revision of the repository in dir. The error wraps fs.ErrNotExist if the
+// file doesn't exist in the revision.
+func FileAtRevision(dir string, rev string, path string) ([]byte, error) {
+	output, err := exec.Command(gitPath(), "-C", dir, "ls-tree", "--name-only", rev, "--", path).Output()
+	if err != nil {
+		return nil, fmt.Errorf("could not read %s at %s: %w", path, rev, err)
+	}
+	if strings.TrimSpace(string(output)) == "" {
+		return nil, fmt.Errorf("could not read %s at %s: %w", path, rev, fs.ErrNotExist)
+	}
+
+	content, err := exec.Command(gitPath(), "-C", dir, "show", rev+":"+path).Output()
+	if err != nil {
+		return nil, fmt.Errorf("could not read %s at %s: %w", path, rev, err)
+	}
+
+	return content, nil
+}
+
+// Checkout clones the repository at url into dir, fetches ref, and checks out
+// sha. Fetching ref allows checking out commits that aren't on a branch, like
+// the head of a pull request from a fork. env is added to the environment of
+// git, e.g. to authenticate.
+func Checkout(url string, dir string, ref string, sha string, env []string) error {
+	commands := [][]string{
+		{"clone", "--quiet", "--no-checkout", url, dir},
+		{"-C", dir, "fetch", "--quiet", "--no-tags", "origin", ref},
+		{"-C", dir, "checkout", "--quiet", "--detach", sha},
+	}
+
+	for _, args := range commands {
+		cmd := exec.Command(gitPath(), args...)
+		cmd.Env = append(os.Environ(), env...)
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("could not check out %s from %s: %w: %s", sha, url, err, strings.TrimSpace(string(output)))
+		}
+	}
+
+	return nil
+}
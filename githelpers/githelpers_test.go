@@ -0,0 +1,116 @@
+package githelpers
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// logRecord builds a commit as printed by `git log` using logFormat.
+func logRecord(fields ...string) string {
+	return "\x1e" + strings.Join(fields, "\x1f")
+}
+
+func TestParseLog(t *testing.T) {
+	date := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	cases := []struct {
+		name    string
+		output  string
+		want    []Commit
+		wantErr string
+	}{
+		{
+			name:   "empty",
+			output: "",
+			want:   []Commit{},
+		},
+		{
+			name:   "single commit",
+			output: logRecord("abc", "Ada", "ada@example.com", "2024-01-02T03:04:05Z", "Bob", "bob@example.com", "2024-01-02T03:04:05Z", "Add app", "Details\n\nSigned-off-by: Ada <ada@example.com>\n", "\n\napp.rb\nREADME.md\n"),
+			want: []Commit{{
+				Sha:            "abc",
+				AuthorName:     "Ada",
+				AuthorEmail:    "ada@example.com",
+				AuthorDate:     date,
+				CommitterName:  "Bob",
+				CommitterEmail: "bob@example.com",
+				CommitterDate:  date,
+				Subject:        "Add app",
+				Body:           "Details\n\nSigned-off-by: Ada <ada@example.com>",
+				Files:          []string{"app.rb", "README.md"},
+			}},
+		},
+		{
+			name: "several commits without files",
+			output: logRecord("abc", "Ada", "a@x", "2024-01-02T03:04:05Z", "Ada", "a@x", "2024-01-02T03:04:05Z", "First", "", "\n") +
+				logRecord("def", "Ada", "a@x", "2024-01-02T03:04:05Z", "Ada", "a@x", "2024-01-02T03:04:05Z", "Second", "", "\n"),
+			want: []Commit{
+				{Sha: "abc", AuthorName: "Ada", AuthorEmail: "a@x", AuthorDate: date, CommitterName: "Ada", CommitterEmail: "a@x", CommitterDate: date, Subject: "First", Files: []string{}},
+				{Sha: "def", AuthorName: "Ada", AuthorEmail: "a@x", AuthorDate: date, CommitterName: "Ada", CommitterEmail: "a@x", CommitterDate: date, Subject: "Second", Files: []string{}},
+			},
+		},
+		{
+			name:    "missing fields",
+			output:  logRecord("abc", "Ada"),
+			wantErr: "could not parse git log output: unexpected field count 2",
+		},
+		{
+			name:    "invalid date",
+			output:  logRecord("abc", "Ada", "a@x", "yesterday", "Ada", "a@x", "2024-01-02T03:04:05Z", "First", "", ""),
+			wantErr: "could not parse author date for abc",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			commits, err := parseLog(c.output)
+			if c.wantErr != "" {
+				require.ErrorContains(t, err, c.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, c.want, commits)
+		})
+	}
+}
+
+func TestDiffWithUntrackedFrom(t *testing.T) {
+	dir := t.TempDir()
+	git := func(args ...string) string {
+		cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		output, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(output))
+		return string(output)
+	}
+
+	git("init", "--quiet")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".gitignore"), []byte("build/\n"), 0o644))
+	git("add", ".")
+	git("commit", "--quiet", "-m", "Initial commit")
+
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "app", "build"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "app", "new.rb"), []byte("puts 1\n"), 0o644))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "build"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "build", "out.rb"), []byte("puts 2\n"), 0o644))
+
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, os.Chdir(cwd)) })
+	// Untracked files outside of the current directory are included too
+	require.NoError(t, os.Chdir(filepath.Join(dir, "app", "build")))
+
+	diff, err := DiffWithUntrackedFrom("HEAD")
+	require.NoError(t, err)
+	require.Contains(t, diff, "diff --git a/app/new.rb b/app/new.rb\nnew file mode 100644")
+	require.NotContains(t, diff, "build/out.rb")
+
+	// The real index is left untouched
+	require.Equal(t, "?? app/\n", git("status", "--porcelain"))
+}
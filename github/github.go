@@ -7,6 +7,7 @@ import (
 	"io"
 	"net/http"
 	"strings"
+	"time"
 )
 
 var ErrNoPR = errors.New("no PR exists for current branch")
@@ -20,7 +21,9 @@ const (
 type (
 	Client interface {
 		DetailsForPull(number int) (*PullRequest, error)
+		DiffForPull(number int) (string, error)
 		PullRequestIDsForBranch(sha string) ([]int, error)
+		CommitsForPull(number int) ([]PullCommit, error)
 		Comment(number int, comment string) error
 		Comments(number int) ([]Comment, error)
 		ReviewComments(number int) ([]Comment, error)
@@ -32,6 +35,7 @@ type (
 	}
 
 	defaultClient struct {
+		baseURL    string
 		token      string
 		owner      string
 		repo       string
@@ -51,10 +55,33 @@ type (
 		Body  string
 		Draft bool
 	}
+
+	// PullCommit represents a commit that is part of a Pull Request
+	PullCommit struct {
+		Sha            string
+		Message        string
+		AuthorName     string
+		AuthorEmail    string
+		AuthorDate     time.Time
+		CommitterName  string
+		CommitterEmail string
+		CommitterDate  time.Time
+		Files          []string
+	}
 )
 
+// DefaultBaseURL is the URL of the GitHub.com API.
+const DefaultBaseURL = "https://api.github.com"
+
 func NewClient(token string, owner string, repo string) Client {
+	return NewClientWithBaseURL(DefaultBaseURL, token, owner, repo)
+}
+
+// NewClientWithBaseURL returns a client for the API at baseURL, like the API of
+// a GitHub Enterprise Server instance.
+func NewClientWithBaseURL(baseURL string, token string, owner string, repo string) Client {
 	return defaultClient{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
 		token:      token,
 		owner:      owner,
 		repo:       repo,
@@ -63,12 +90,12 @@ func NewClient(token string, owner string, repo string) Client {
 }
 
 func (c defaultClient) ReviewComments(number int) ([]Comment, error) {
-	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/pulls/%d/comments?per_page=100", c.owner, c.repo, number)
+	url := fmt.Sprintf("%s/repos/%s/%s/pulls/%d/comments?per_page=100", c.baseURL, c.owner, c.repo, number)
 	return c.fetchComments(url, FileComment)
 }
 
 func (c defaultClient) Comments(number int) ([]Comment, error) {
-	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/issues/%d/comments?per_page=100", c.owner, c.repo, number)
+	url := fmt.Sprintf("%s/repos/%s/%s/issues/%d/comments?per_page=100", c.baseURL, c.owner, c.repo, number)
 	return c.fetchComments(url, ReviewComment)
 }
 
@@ -112,7 +139,7 @@ func (c defaultClient) fetchComments(url string, ct CommentType) ([]Comment, err
 }
 
 func (c defaultClient) DetailsForPull(number int) (*PullRequest, error) {
-	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/pulls/%d", c.owner, c.repo, number)
+	url := fmt.Sprintf("%s/repos/%s/%s/pulls/%d", c.baseURL, c.owner, c.repo, number)
 
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
@@ -146,8 +173,39 @@ func (c defaultClient) DetailsForPull(number int) (*PullRequest, error) {
 	return pullRequest, nil
 }
 
+// DiffForPull returns the diff of the pull request, from its merge base to
+// its head.
+func (c defaultClient) DiffForPull(number int) (string, error) {
+	url := fmt.Sprintf("%s/repos/%s/%s/pulls/%d", c.baseURL, c.owner, c.repo, number)
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Accept", "application/vnd.github.v3.diff")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status: %d, body: %s", resp.StatusCode, body)
+	}
+
+	return string(body), nil
+}
+
 func (c defaultClient) PullRequestIDsForBranch(branch string) ([]int, error) {
-	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/pulls?head=%s:%s", c.owner, c.repo, c.owner, branch)
+	url := fmt.Sprintf("%s/repos/%s/%s/pulls?head=%s:%s", c.baseURL, c.owner, c.repo, c.owner, branch)
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
@@ -189,8 +247,146 @@ func (c defaultClient) PullRequestIDsForBranch(branch string) ([]int, error) {
 	return numbers, nil
 }
 
+// commitsPerPage is the most commits the pull request commits endpoint
+// returns at once.
+const commitsPerPage = 100
+
+type commitSignature struct {
+	Name  string    `json:"name"`
+	Email string    `json:"email"`
+	Date  time.Time `json:"date"`
+}
+
+type commitResponse struct {
+	Sha    string `json:"sha"`
+	Commit struct {
+		Message   string          `json:"message"`
+		Author    commitSignature `json:"author"`
+		Committer commitSignature `json:"committer"`
+	} `json:"commit"`
+}
+
+func (c defaultClient) CommitsForPull(number int) ([]PullCommit, error) {
+	var commits []commitResponse
+	for page := 1; ; page++ {
+		pageCommits, err := c.commitsForPullPage(number, page)
+		if err != nil {
+			return nil, err
+		}
+
+		commits = append(commits, pageCommits...)
+		if len(pageCommits) < commitsPerPage {
+			break
+		}
+	}
+
+	pullCommits := make([]PullCommit, len(commits))
+	for i, commit := range commits {
+		files, err := c.filesForCommit(commit.Sha)
+		if err != nil {
+			return nil, err
+		}
+
+		pullCommits[i] = PullCommit{
+			Sha:            commit.Sha,
+			Message:        commit.Commit.Message,
+			AuthorName:     commit.Commit.Author.Name,
+			AuthorEmail:    commit.Commit.Author.Email,
+			AuthorDate:     commit.Commit.Author.Date,
+			CommitterName:  commit.Commit.Committer.Name,
+			CommitterEmail: commit.Commit.Committer.Email,
+			CommitterDate:  commit.Commit.Committer.Date,
+			Files:          files,
+		}
+	}
+
+	return pullCommits, nil
+}
+
+// commitsForPullPage returns a single page of the commits of the pull
+// request, pages starting at 1.
+func (c defaultClient) commitsForPullPage(number int, page int) ([]commitResponse, error) {
+	url := fmt.Sprintf("%s/repos/%s/%s/pulls/%d/commits?per_page=%d&page=%d", c.baseURL, c.owner, c.repo, number, commitsPerPage, page)
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("unexpected status: %d, body: %s", resp.StatusCode, body)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	var commits []commitResponse
+	if err := json.Unmarshal(body, &commits); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+
+	return commits, nil
+}
+
+// filesForCommit returns the names of the files touched by the given commit.
+// The pull request commits endpoint doesn't include files, so each commit has
+// to be fetched individually.
+func (c defaultClient) filesForCommit(sha string) ([]string, error) {
+	url := fmt.Sprintf("%s/repos/%s/%s/commits/%s", c.baseURL, c.owner, c.repo, sha)
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("unexpected status: %d, body: %s", resp.StatusCode, body)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	var commit struct {
+		Files []struct {
+			Filename string `json:"filename"`
+		} `json:"files"`
+	}
+	if err := json.Unmarshal(body, &commit); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+
+	files := make([]string, len(commit.Files))
+	for i, file := range commit.Files {
+		files[i] = file.Filename
+	}
+
+	return files, nil
+}
+
 func (c defaultClient) Comment(number int, comment string) error {
-	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/issues/%d/comments", c.owner, c.repo, number)
+	url := fmt.Sprintf("%s/repos/%s/%s/issues/%d/comments", c.baseURL, c.owner, c.repo, number)
 	payload := map[string]string{"body": comment}
 	payloadBytes, err := json.Marshal(payload)
 	if err != nil {
@@ -230,7 +426,7 @@ type NewFileComment struct {
 }
 
 func (c defaultClient) FileComment(fc NewFileComment) error {
-	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/pulls/%d/comments", c.owner, c.repo, fc.Number)
+	url := fmt.Sprintf("%s/repos/%s/%s/pulls/%d/comments", c.baseURL, c.owner, c.repo, fc.Number)
 	payload := map[string]interface{}{
 		"body":      fc.Text,
 		"commit_id": fc.Sha,
@@ -273,7 +469,7 @@ func (c defaultClient) ResolveFileComment(comment Comment) error {
 	}
 
 	// Send the updated comment
-	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/pulls/comments/%d", c.owner, c.repo, comment.Id)
+	url := fmt.Sprintf("%s/repos/%s/%s/pulls/comments/%d", c.baseURL, c.owner, c.repo, comment.Id)
 	payload := map[string]interface{}{
 		"body":     comment.Body,
 	}
@@ -312,7 +508,7 @@ func (c defaultClient) ResolveComment(comment Comment) error {
 	}
 
 	// Send the updated comment
-	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/issues/comments/%d", c.owner, c.repo, comment.Id)
+	url := fmt.Sprintf("%s/repos/%s/%s/issues/comments/%d", c.baseURL, c.owner, c.repo, comment.Id)
 	payload := map[string]interface{}{
 		"body": comment.Body,
 	}
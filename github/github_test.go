@@ -1,2 +1,46 @@
 package github
 
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommitsForPull_Paginates(t *testing.T) {
+	const total = 150
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/octo/app/pulls/1/commits", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "100", r.URL.Query().Get("per_page"))
+		page, err := strconv.Atoi(r.URL.Query().Get("page"))
+		require.NoError(t, err)
+
+		commits := make([]map[string]any, 0)
+		for n := (page - 1) * 100; n < min(page*100, total); n++ {
+			commits = append(commits, map[string]any{
+				"sha":    fmt.Sprintf("sha%d", n),
+				"commit": map[string]any{"message": fmt.Sprintf("Commit %d", n)},
+			})
+		}
+		require.NoError(t, json.NewEncoder(w).Encode(commits))
+	})
+	mux.HandleFunc("/repos/octo/app/commits/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"files":[{"filename":"app.rb"}]}`)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	client := NewClientWithBaseURL(server.URL, "token", "octo", "app")
+	commits, err := client.CommitsForPull(1)
+	require.NoError(t, err)
+
+	require.Len(t, commits, total)
+	require.Equal(t, "sha0", commits[0].Sha)
+	require.Equal(t, "Commit 149", commits[total-1].Message)
+	require.Equal(t, []string{"app.rb"}, commits[total-1].Files)
+}
@@ -0,0 +1,148 @@
+package github
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	// SignatureHeader holds the HMAC of webhook deliveries, signed using the
+	// webhook secret.
+	SignatureHeader = "X-Hub-Signature-256"
+	// EventHeader holds the name of the event of webhook deliveries.
+	EventHeader = "X-GitHub-Event"
+	// DeliveryHeader holds the unique ID of webhook deliveries.
+	DeliveryHeader = "X-GitHub-Delivery"
+)
+
+var ErrInvalidSignature = errors.New("webhook signature does not match the payload")
+
+// ValidateSignature returns ErrInvalidSignature unless signature, the value of
+// SignatureHeader, is the HMAC of the payload signed using secret.
+func ValidateSignature(secret []byte, signature string, payload []byte) error {
+	digest, ok := strings.CutPrefix(signature, "sha256=")
+	if !ok {
+		return ErrInvalidSignature
+	}
+
+	expected, err := hex.DecodeString(digest)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(payload)
+	if !hmac.Equal(mac.Sum(nil), expected) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+// Sign returns the value of SignatureHeader for the payload, signed using
+// secret.
+func Sign(secret []byte, payload []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(payload)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// WebhookPull is a pull request a webhook delivery asks to be checked.
+type WebhookPull struct {
+	Owner    string
+	Repo     string
+	CloneURL string
+	Number   int
+	HeadSha  string
+	BaseSha  string
+}
+
+type webhookRepository struct {
+	Name     string `json:"name"`
+	CloneURL string `json:"clone_url"`
+	Owner    struct {
+		Login string `json:"login"`
+	} `json:"owner"`
+}
+
+type webhookPull struct {
+	Number int `json:"number"`
+	Head   struct {
+		Sha string `json:"sha"`
+	} `json:"head"`
+	Base struct {
+		Sha string `json:"sha"`
+	} `json:"base"`
+}
+
+// pullActions are the actions of the pull_request event that change what the
+// checkers see.
+var pullActions = map[string]bool{
+	"opened":           true,
+	"reopened":         true,
+	"synchronize":      true,
+	"edited":           true,
+	"ready_for_review": true,
+}
+
+// PullsForEvent returns the pull requests to check for a webhook delivery of
+// the given event. Deliveries of other events, or of actions that don't change
+// the pull request, return no pull requests.
+func PullsForEvent(event string, payload []byte) ([]WebhookPull, error) {
+	switch event {
+	case "pull_request":
+		var delivery struct {
+			Action      string            `json:"action"`
+			PullRequest webhookPull       `json:"pull_request"`
+			Repository  webhookRepository `json:"repository"`
+		}
+		if err := json.Unmarshal(payload, &delivery); err != nil {
+			return nil, fmt.Errorf("failed to parse pull_request payload: %w", err)
+		}
+		if !pullActions[delivery.Action] {
+			return nil, nil
+		}
+
+		return []WebhookPull{newWebhookPull(delivery.Repository, delivery.PullRequest)}, nil
+	case "check_suite":
+		var delivery struct {
+			Action     string `json:"action"`
+			CheckSuite struct {
+				PullRequests []webhookPull `json:"pull_requests"`
+			} `json:"check_suite"`
+			Repository webhookRepository `json:"repository"`
+		}
+		if err := json.Unmarshal(payload, &delivery); err != nil {
+			return nil, fmt.Errorf("failed to parse check_suite payload: %w", err)
+		}
+		if delivery.Action != "requested" && delivery.Action != "rerequested" {
+			return nil, nil
+		}
+
+		pulls := make([]WebhookPull, len(delivery.CheckSuite.PullRequests))
+		for i, pull := range delivery.CheckSuite.PullRequests {
+			pulls[i] = newWebhookPull(delivery.Repository, pull)
+		}
+
+		return pulls, nil
+	default:
+		return nil, nil
+	}
+}
+
+func newWebhookPull(repo webhookRepository, pull webhookPull) WebhookPull {
+	return WebhookPull{
+		Owner:    repo.Owner.Login,
+		Repo:     repo.Name,
+		CloneURL: repo.CloneURL,
+		Number:   pull.Number,
+		HeadSha:  pull.Head.Sha,
+		BaseSha:  pull.Base.Sha,
+	}
+}
@@ -0,0 +1,64 @@
+package github
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateSignature(t *testing.T) {
+	secret := []byte("s3cret")
+	payload := []byte(`{"action":"opened"}`)
+
+	require.NoError(t, ValidateSignature(secret, Sign(secret, payload), payload))
+	require.ErrorIs(t, ValidateSignature(secret, Sign([]byte("other"), payload), payload), ErrInvalidSignature)
+	require.ErrorIs(t, ValidateSignature(secret, Sign(secret, payload), []byte(`{}`)), ErrInvalidSignature)
+	require.ErrorIs(t, ValidateSignature(secret, "sha256=nothex", payload), ErrInvalidSignature)
+	require.ErrorIs(t, ValidateSignature(secret, "", payload), ErrInvalidSignature)
+}
+
+const repository = `"repository":{"name":"manifest","clone_url":"https://github.com/blakewilliams/manifest.git","owner":{"login":"blakewilliams"}}`
+
+func TestPullsForEvent_PullRequest(t *testing.T) {
+	payload := `{"action":"synchronize","pull_request":{"number":12,"head":{"sha":"abc"},"base":{"sha":"def"}},` + repository + `}`
+
+	pulls, err := PullsForEvent("pull_request", []byte(payload))
+	require.NoError(t, err)
+	require.Equal(t, []WebhookPull{{
+		Owner:    "blakewilliams",
+		Repo:     "manifest",
+		CloneURL: "https://github.com/blakewilliams/manifest.git",
+		Number:   12,
+		HeadSha:  "abc",
+		BaseSha:  "def",
+	}}, pulls)
+
+	pulls, err = PullsForEvent("pull_request", []byte(`{"action":"closed",`+repository+`}`))
+	require.NoError(t, err)
+	require.Empty(t, pulls)
+}
+
+func TestPullsForEvent_CheckSuite(t *testing.T) {
+	payload := `{"action":"rerequested","check_suite":{"pull_requests":[` +
+		`{"number":1,"head":{"sha":"a"},"base":{"sha":"b"}},` +
+		`{"number":2,"head":{"sha":"a"},"base":{"sha":"c"}}]},` + repository + `}`
+
+	pulls, err := PullsForEvent("check_suite", []byte(payload))
+	require.NoError(t, err)
+	require.Len(t, pulls, 2)
+	require.Equal(t, 2, pulls[1].Number)
+	require.Equal(t, "c", pulls[1].BaseSha)
+
+	pulls, err = PullsForEvent("check_suite", []byte(`{"action":"completed",`+repository+`}`))
+	require.NoError(t, err)
+	require.Empty(t, pulls)
+}
+
+func TestPullsForEvent_Ignored(t *testing.T) {
+	pulls, err := PullsForEvent("ping", []byte(`{"zen":"Keep it logically awesome."}`))
+	require.NoError(t, err)
+	require.Empty(t, pulls)
+
+	_, err = PullsForEvent("pull_request", []byte(`nope`))
+	require.Error(t, err)
+}
@@ -9,22 +9,53 @@ import (
 	"io"
 	"os"
 	"os/exec"
-	"sync"
+	"slices"
+	"sync/atomic"
 
+	"github.com/blakewilliams/manifest/githelpers"
 	"github.com/blakewilliams/manifest/github"
+	"github.com/blakewilliams/manifest/pkg/codeowners"
+	"github.com/blakewilliams/manifest/pkg/gitattributes"
 	"github.com/blakewilliams/manifest/pkg/multierror"
 	"golang.org/x/sync/errgroup"
 )
 
 var ErrCheckReportedError = errors.New("one or more checkers reported an error")
 
+// ErrFormatterFailed is wrapped by the errors returned by formatters.
+var ErrFormatterFailed = errors.New("formatter failed")
+
+// CheckerFailureError is returned when a checker reports a failure in its
+// result, as opposed to failing to run.
+type CheckerFailureError struct {
+	Checker string
+	Reason  string
+}
+
+func (e *CheckerFailureError) Error() string {
+	return fmt.Sprintf("Check %s failed with reported reason: %s", e.Checker, e.Reason)
+}
+
 type Check struct {
 	config *Configuration
 	Import *Import
+	// Dir is the directory the checkers are run in, the current directory
+	// when empty.
+	Dir string
+	// Env is the environment of the checkers, the environment of manifest
+	// when nil. It keeps secrets away from checkers that can't be trusted.
+	Env []string
+
+	// commitImports holds one import per commit when running in per-commit
+	// mode.
+	commitImports []*Import
+
+	// base is the base of the change when running in differential mode.
+	base *differentialBase
 }
 
 func NewCheck(c *Configuration, diffReader io.Reader) (*Check, error) {
-	diff, err := NewDiff(diffReader)
+	diff, err := NewDiff(diffReader, c.DiffOptions()...)
 	if err != nil {
 		return nil, fmt.Errorf("could not create diff: %w", err)
 	}
@@ -37,6 +68,49 @@ func NewCheck(c *Configuration, diffReader io.Reader) (*Check, error) {
 	return check, nil
 }
 
+// NewCheckFromDiff returns a check for an already parsed diff, like the diff
+// returned by NewScanDiff.
+func NewCheckFromDiff(c *Configuration, diff Diff) *Check {
+	return &Check{
+		config: c,
+		Import: &Import{Strict: c.Strict, Diff: diff},
+	}
+}
+
+// NewCheckFromImport returns a check for a previously saved import, like the
+// output of `manifest check --json-only`, so that it can be replayed.
+func NewCheckFromImport(c *Configuration, imp *Import) *Check {
+	if c.Strict {
+		imp.Strict = true
+	}
+
+	return &Check{config: c, Import: imp}
+}
+
+// NewCheckFromPatches returns a check for the combined changes of a patch
+// series. The commits in the series are included in the import and the cover
+// letter, if present, is used as the pull request title and description.
+func NewCheckFromPatches(c *Configuration, series *PatchSeries) *Check {
+	commits := make([]Commit, len(series.Patches))
+	for n, patch := range series.Patches {
+		commits[n] = patch.Commit
+	}
+
+	check := &Check{
+		config: c,
+		Import: &Import{Strict: c.Strict, Diff: series.Diff, Commits: commits},
+	}
+
+	if series.CoverLetter != nil {
+		check.Import.Pull = &Pull{
+			Title:       series.CoverLetter.Subject,
+			Description: series.CoverLetter.Body,
+		}
+	}
+
+	return check
+}
+
 func (i *Check) PopulatePullDetails(gh github.Client, sha string, prNum int) error {
 	pr, err := gh.DetailsForPull(prNum)
 	if err != nil {
@@ -57,8 +131,89 @@ func (i *Check) PopulatePullDetails(gh github.Client, sha string, prNum int) err
 	return nil
 }
 
+// PopulateCommits populates the commit list using the local commits in
+// `base..head`.
+func (i *Check) PopulateCommits(base string, head string) error {
+	commits, err := githelpers.Commits(base, head)
+	if err != nil {
+		return err
+	}
+
+	i.Import.Commits = make([]Commit, len(commits))
+	for n, commit := range commits {
+		i.Import.Commits[n] = Commit{
+			Sha:       commit.Sha,
+			Author:    Signature{Name: commit.AuthorName, Email: commit.AuthorEmail, Date: commit.AuthorDate},
+			Committer: Signature{Name: commit.CommitterName, Email: commit.CommitterEmail, Date: commit.CommitterDate},
+			Subject:   commit.Subject,
+			Body:      commit.Body,
+			Trailers:  parseTrailers(commit.Body),
+			Files:     commit.Files,
+		}
+	}
+
+	return nil
+}
+
+// PopulatePullCommits populates the commit list using the commits in the given
+// pull request. It's used when the commits aren't available locally.
+func (i *Check) PopulatePullCommits(gh github.Client, prNum int) error {
+	commits, err := gh.CommitsForPull(prNum)
+	if err != nil {
+		return err
+	}
+
+	i.Import.Commits = make([]Commit, len(commits))
+	for n, commit := range commits {
+		subject, body := splitCommitMessage(commit.Message)
+
+		i.Import.Commits[n] = Commit{
+			Sha:       commit.Sha,
+			Author:    Signature{Name: commit.AuthorName, Email: commit.AuthorEmail, Date: commit.AuthorDate},
+			Committer: Signature{Name: commit.CommitterName, Email: commit.CommitterEmail, Date: commit.CommitterDate},
+			Subject:   subject,
+			Body:      body,
+			Trailers:  parseTrailers(body),
+			Files:     commit.Files,
+		}
+	}
+
+	return nil
+}
+
+// ApplyAttributes annotates the files of every diff in the check with their
+// gitattributes.
+func (i *Check) ApplyAttributes(attributes *gitattributes.Attributes) {
+	i.Import.Diff.ApplyAttributes(attributes)
+	for _, imp := range i.commitImports {
+		imp.Diff.ApplyAttributes(attributes)
+	}
+}
+
+// ApplyCodeOwners annotates the files of every diff in the check with their
+// CODEOWNERS owners.
+func (i *Check) ApplyCodeOwners(co *codeowners.CodeOwners) {
+	i.Import.Diff.ApplyCodeOwners(co)
+	for _, imp := range i.commitImports {
+		imp.Diff.ApplyCodeOwners(co)
+	}
+}
+
+// checkerImport returns the import as it should be passed to checkers, taking
+// configuration like ExcludeGenerated into account.
+func (i *Check) checkerImport(imp *Import) *Import {
+	if !i.config.ExcludeGenerated {
+		return imp
+	}
+
+	filtered := *imp
+	filtered.Diff = imp.Diff.WithoutGenerated()
+
+	return &filtered
+}
+
 func (i *Check) ImportJSON() ([]byte, error) {
-	out, err := json.Marshal(i.Import)
+	out, err := json.Marshal(i.checkerImport(i.Import))
 	if err != nil {
 		return nil, fmt.Errorf("could not marshall output for import JSON: %w", err)
 	}
@@ -66,17 +221,89 @@ func (i *Check) ImportJSON() ([]byte, error) {
 	return out, nil
 }
 
+// AddCommitDiff adds the diff introduced by a single commit. The commit
+// metadata is looked up in the import's commits.
+func (i *Check) AddCommitDiff(sha string, diffReader io.Reader) error {
+	diff, err := NewDiff(diffReader, i.config.DiffOptions()...)
+	if err != nil {
+		return fmt.Errorf("could not create diff for commit %s: %w", sha, err)
+	}
+
+	commit := Commit{Sha: sha}
+	for _, c := range i.Import.Commits {
+		if c.Sha == sha {
+			commit = c
+			break
+		}
+	}
+
+	i.AddCommit(commit, diff)
+
+	return nil
+}
+
+// AddCommit adds a commit and the diff it introduced. When commits are added,
+// Perform runs the checkers once per commit instead of once for the whole
+// diff.
+func (i *Check) AddCommit(commit Commit, diff Diff) {
+	i.commitImports = append(i.commitImports, &Import{
+		Pull:       i.Import.Pull,
+		CurrentSha: commit.Sha,
+		Strict:     i.Import.Strict,
+		Diff:       diff,
+		Commit:     &commit,
+	})
+}
+
 // Perform accepts a configuration and a diff, then runs + reports on the rules
 // based on the configuration+output.
 func (i *Check) Perform() error {
-	importJSON, err := i.ImportJSON()
-	if err != nil {
-		return err
+	imports := []*Import{i.checkerImport(i.Import)}
+	if len(i.commitImports) > 0 {
+		imports = make([]*Import, len(i.commitImports))
+		for n, imp := range i.commitImports {
+			imports[n] = i.checkerImport(imp)
+		}
+	}
+
+	importJSON := make([][]byte, len(imports))
+	importPaths := make([]string, len(imports))
+	for n, imp := range imports {
+		out, err := json.Marshal(imp)
+		if err != nil {
+			return fmt.Errorf("could not marshall output for import JSON: %w", err)
+		}
+
+		// Large imports are written to a file that every checker reads from,
+		// instead of being copied to each checker from memory.
+		if i.config.ImportFileThreshold > 0 && int64(len(out)) > i.config.ImportFileThreshold {
+			path, err := writeImportFile(out)
+			if err != nil {
+				return err
+			}
+			defer os.Remove(path)
+
+			importPaths[n] = path
+			continue
+		}
+
+		importJSON[n] = out
+	}
+
+	var baseJSON []byte
+	if i.base != nil {
+		out, err := json.Marshal(i.checkerImport(i.base.imp))
+		if err != nil {
+			return fmt.Errorf("could not marshall output for base import JSON: %w", err)
+		}
+		baseJSON = out
 	}
 
 	// TODO add a timout config
 	g, ctx := errgroup.WithContext(context.Background())
-	g.SetLimit(i.config.Concurrency)
+	if i.config.Concurrency > 0 {
+		g.SetLimit(i.config.Concurrency)
+	}
 
 	if f, ok := i.config.Formatter.(FormatterWithHooks); ok {
 		err := f.BeforeAll(i.Import)
@@ -88,63 +315,68 @@ func (i *Check) Perform() error {
 		defer f.AfterAll(i.Import)
 	}
 
-	var wg sync.WaitGroup
 	multiErr := &multierror.Error{}
 
-	hasCheckErrors := false
-
-	for name, check := range i.config.Checkers {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
+	var hasCheckErrors atomic.Bool
 
-			if ctx.Err() != nil {
-				return
+	for n, imp := range imports {
+		for name, check := range i.config.Checkers {
+			if check.Disabled || !check.AppliesTo(imp.Diff) {
+				continue
 			}
 
-			cmd := exec.Command("sh", "-c", check)
-			cmd.Stdin = bytes.NewReader(importJSON)
-			output, err := cmd.Output()
-			if err != nil {
-				multiErr.Add(fmt.Errorf("`%s` check failed to run: %w", name, err))
-				fmt.Fprint(os.Stderr, string(output))
-				return
-			}
+			g.Go(func() error {
+				if ctx.Err() != nil {
+					return nil
+				}
 
-			var result Result
-			err = json.Unmarshal(output, &result)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "Failed to parse output for check %s: %s\n", name, err)
-				fmt.Fprint(os.Stderr, string(output))
-				multiErr.Add(err)
-				return
-			}
+				result, err := runChecker(name, check.Command, importJSON[n], importPaths[n], i.Dir, i.Env)
+				if err != nil {
+					multiErr.Add(err)
+					return nil
+				}
 
-			if result.Failure != "" {
-				multiErr.Add(fmt.Errorf("Check %s failed with reported reason: %s", name, result.Failure))
-				return
-			}
+				if check.Differential && i.base != nil {
+					baseResult, err := runChecker(name, check.Command, baseJSON, "", i.base.dir, i.Env)
+					if err != nil {
+						multiErr.Add(fmt.Errorf("against base: %w", err))
+						return nil
+					}
 
-			if !hasCheckErrors {
-				for _, comment := range result.Comments {
-					if comment.Severity == SeverityError {
-						hasCheckErrors = true
-						break
+					introduced, fixed := differentialComments(imp.Diff, result.Comments, baseResult.Comments)
+					result.Comments = introduced
+					if i.config.ShowFixed {
+						result.Comments = append(result.Comments, fixed...)
 					}
 				}
-			}
 
-			if err := i.config.Formatter.Format(name, i.Import, result); err != nil {
-				multiErr.Add(err)
-				return
-			}
-		}()
+				if i.config.Suppressions != nil {
+					result.Comments = i.config.Suppressions.Filter(name, result.Comments)
+				}
+
+				for c := range result.Comments {
+					if imp.Commit != nil {
+						result.Comments[c].Commit = imp.Commit.Sha
+					}
+
+					if result.Comments[c].Severity == SeverityError && !i.ignoreComment(imp, result.Comments[c]) {
+						hasCheckErrors.Store(true)
+					}
+				}
+
+				if err := i.config.Formatter.Format(name, imp, result); err != nil {
+					multiErr.Add(fmt.Errorf("%w: %w", ErrFormatterFailed, err))
+				}
+
+				return nil
+			})
+		}
 	}
 
-	wg.Wait()
+	_ = g.Wait()
 
 	if multiErr.None() {
-		if hasCheckErrors {
+		if hasCheckErrors.Load() {
 			return ErrCheckReportedError
 		}
 
@@ -153,3 +385,96 @@ func (i *Check) Perform() error {
 
 	return multiErr.ErrorOrNil()
 }
+
+// ignoreComment returns true if the comment shouldn't count towards failing
+// the check.
+func (i *Check) ignoreComment(imp *Import, comment Comment) bool {
+	if i.config.IgnoreMoved && comment.File != "" && comment.Line != 0 {
+		return imp.Diff.IsMovedLine(comment.File, comment.Side, comment.Line)
+	}
+
+	return false
+}
+
+// ImportFileEnv is the environment variable containing the path to the import
+// JSON when it is passed to checkers using a temporary file.
+const ImportFileEnv = "MANIFEST_IMPORT_FILE"
+
+// writeImportFile writes the import JSON to a temporary file, returning its
+// path.
+func writeImportFile(importJSON []byte) (string, error) {
+	f, err := os.CreateTemp("", "manifest-import-*.json")
+	if err != nil {
+		return "", fmt.Errorf("could not create import file: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(importJSON); err != nil {
+		os.Remove(f.Name())
+		return "", fmt.Errorf("could not write import file: %w", err)
+	}
+
+	return f.Name(), nil
+}
+
+// RunChecker runs the given checker command with the import JSON as stdin and
+// returns its result, including the failure it reported, if any.
+func RunChecker(name string, check string, importJSON []byte) (Result, error) {
+	return execChecker(name, check, importJSON, "", "", nil)
+}
+
+// runChecker runs the given checker command and returns its result, returning
+// an error if the checker reported a failure.
+func runChecker(name string, check string, importJSON []byte, importPath string, dir string, env []string) (Result, error) {
+	result, err := execChecker(name, check, importJSON, importPath, dir, env)
+	if err != nil {
+		return Result{}, err
+	}
+
+	if result.Failure != "" {
+		return Result{}, &CheckerFailureError{Checker: name, Reason: result.Failure}
+	}
+
+	return result, nil
+}
+
+// execChecker runs the given checker command with the import JSON as stdin and
+// returns the parsed result. When importPath is set, the import is read from
+// that file instead and its path is passed to the checker in ImportFileEnv.
+// The checker is run in dir, or the current directory if it's empty, with env
+// as its environment, or the environment of manifest if env is nil.
+func execChecker(name string, check string, importJSON []byte, importPath string, dir string, env []string) (Result, error) {
+	cmd := exec.Command("sh", "-c", check)
+	cmd.Dir = dir
+	if env == nil {
+		env = os.Environ()
+	}
+	cmd.Env = slices.Clip(env)
+	if importPath != "" {
+		f, err := os.Open(importPath)
+		if err != nil {
+			return Result{}, fmt.Errorf("`%s` check could not open import file: %w", name, err)
+		}
+		defer f.Close()
+
+		cmd.Stdin = f
+		cmd.Env = append(cmd.Env, ImportFileEnv+"="+importPath)
+	} else {
+		cmd.Stdin = bytes.NewReader(importJSON)
+	}
+	output, err := cmd.Output()
+	if err != nil {
+		fmt.Fprint(os.Stderr, string(output))
+		return Result{}, fmt.Errorf("`%s` check failed to run: %w", name, err)
+	}
+
+	var result Result
+	err = json.Unmarshal(output, &result)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to parse output for check %s: %s\n", name, err)
+		fmt.Fprint(os.Stderr, string(output))
+		return Result{}, err
+	}
+
+	return result, nil
+}
@@ -0,0 +1,131 @@
+package manifest
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type recordingFormatter struct {
+	mu      sync.Mutex
+	imports []*Import
+	results []Result
+}
+
+func (f *recordingFormatter) Format(source string, i *Import, r Result) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.imports = append(f.imports, i)
+	f.results = append(f.results, r)
+
+	return nil
+}
+
+func TestPerform_PerCommit(t *testing.T) {
+	formatter := &recordingFormatter{}
+	config := &Configuration{
+		Concurrency: 2,
+		Formatter:   formatter,
+		Checkers: map[string]Checker{
+			"warn": {Command: `echo '{"comments":[{"text":"hello","severity":"Warn"}]}'`},
+		},
+	}
+
+	check, err := NewCheck(config, strings.NewReader(newFile))
+	require.NoError(t, err)
+
+	check.Import.Commits = []Commit{{Sha: "abc", Subject: "Add README"}, {Sha: "def"}}
+	require.NoError(t, check.AddCommitDiff("abc", strings.NewReader(newFile)))
+	require.NoError(t, check.AddCommitDiff("def", strings.NewReader(newFile)))
+
+	require.NoError(t, check.Perform())
+
+	require.Len(t, formatter.results, 2)
+
+	commits := make(map[string]string)
+	for n, result := range formatter.results {
+		require.Len(t, result.Comments, 1)
+		require.Equal(t, formatter.imports[n].Commit.Sha, result.Comments[0].Commit)
+		require.Equal(t, formatter.imports[n].Commit.Sha, formatter.imports[n].CurrentSha)
+		commits[result.Comments[0].Commit] = formatter.imports[n].Commit.Subject
+	}
+
+	require.Equal(t, map[string]string{"abc": "Add README", "def": ""}, commits)
+}
+
+func TestPerform_ReportsErrors(t *testing.T) {
+	config := &Configuration{
+		Concurrency: 1,
+		Formatter:   &recordingFormatter{},
+		Checkers: map[string]Checker{
+			"error": {Command: `echo '{"comments":[{"text":"nope","severity":"Error"}]}'`},
+		},
+	}
+
+	check, err := NewCheck(config, strings.NewReader(newFile))
+	require.NoError(t, err)
+
+	require.ErrorIs(t, check.Perform(), ErrCheckReportedError)
+}
+
+func TestPerform_ReportsCheckerFailures(t *testing.T) {
+	config := &Configuration{
+		Concurrency: 1,
+		Formatter:   &recordingFormatter{},
+		Checkers: map[string]Checker{
+			"failure": {Command: `echo '{"failure":"No description"}'`},
+		},
+	}
+
+	check, err := NewCheck(config, strings.NewReader(newFile))
+	require.NoError(t, err)
+
+	var failure *CheckerFailureError
+	require.ErrorAs(t, check.Perform(), &failure)
+	require.Equal(t, &CheckerFailureError{Checker: "failure", Reason: "No description"}, failure)
+}
+
+func TestPerform_SkipsCheckers(t *testing.T) {
+	formatter := &recordingFormatter{}
+	warn := `echo '{"comments":[{"text":"hello","severity":"Warn"}]}'`
+	config := &Configuration{
+		Concurrency: 1,
+		Formatter:   formatter,
+		Checkers: map[string]Checker{
+			"disabled":  {Command: warn, Disabled: true},
+			"unmatched": {Command: warn, Paths: []string{"app/**"}},
+			"matched":   {Command: warn, Paths: []string{"*.md"}},
+		},
+	}
+
+	check, err := NewCheck(config, strings.NewReader(newFile))
+	require.NoError(t, err)
+	require.NoError(t, check.Perform())
+
+	require.Len(t, formatter.results, 1)
+}
+
+func TestNewCheckFromImport(t *testing.T) {
+	config := &Configuration{}
+	check, err := NewCheck(config, strings.NewReader(newFile))
+	require.NoError(t, err)
+	check.Import.Pull = &Pull{Title: "Add README", Number: 2}
+	check.Import.CurrentSha = "abc"
+
+	saved, err := check.ImportJSON()
+	require.NoError(t, err)
+
+	imp := &Import{}
+	require.NoError(t, json.Unmarshal(saved, imp))
+
+	replayed, err := NewCheckFromImport(config, imp).ImportJSON()
+	require.NoError(t, err)
+	require.JSONEq(t, string(saved), string(replayed))
+
+	strict := NewCheckFromImport(&Configuration{Strict: true}, imp)
+	require.True(t, strict.Import.Strict)
+}
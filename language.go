@@ -0,0 +1,220 @@
+package manifest
+
+import (
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/blakewilliams/manifest/pkg/gitattributes"
+)
+
+// languagesByExtension maps file extensions to the language they contain.
+var languagesByExtension = map[string]string{
+	".c":     "C",
+	".h":     "C",
+	".cc":    "C++",
+	".cpp":   "C++",
+	".hpp":   "C++",
+	".cs":    "C#",
+	".css":   "CSS",
+	".scss":  "SCSS",
+	".erb":   "HTML+ERB",
+	".ex":    "Elixir",
+	".exs":   "Elixir",
+	".go":    "Go",
+	".html":  "HTML",
+	".java":  "Java",
+	".js":    "JavaScript",
+	".jsx":   "JavaScript",
+	".mjs":   "JavaScript",
+	".cjs":   "JavaScript",
+	".json":  "JSON",
+	".kt":    "Kotlin",
+	".lua":   "Lua",
+	".md":    "Markdown",
+	".php":   "PHP",
+	".pl":    "Perl",
+	".proto": "Protocol Buffer",
+	".py":    "Python",
+	".rake":  "Ruby",
+	".rb":    "Ruby",
+	".rs":    "Rust",
+	".scala": "Scala",
+	".sh":    "Shell",
+	".bash":  "Shell",
+	".sql":   "SQL",
+	".swift": "Swift",
+	".toml":  "TOML",
+	".ts":    "TypeScript",
+	".tsx":   "TSX",
+	".yaml":  "YAML",
+	".yml":   "YAML",
+}
+
+// languagesByFilename maps well known file names without a meaningful
+// extension to the language they contain.
+var languagesByFilename = map[string]string{
+	"Dockerfile": "Dockerfile",
+	"Gemfile":    "Ruby",
+	"Makefile":   "Makefile",
+	"Rakefile":   "Ruby",
+	"go.mod":     "Go Module",
+}
+
+// languagesByInterpreter maps shebang interpreters to the language they run.
+var languagesByInterpreter = map[string]string{
+	"bash":    "Shell",
+	"node":    "JavaScript",
+	"perl":    "Perl",
+	"python":  "Python",
+	"python3": "Python",
+	"ruby":    "Ruby",
+	"sh":      "Shell",
+	"zsh":     "Shell",
+}
+
+// generatedSuffixes are file name suffixes that are always generated code.
+var generatedSuffixes = []string{
+	".pb.go",
+	"_pb2.py",
+	".min.js",
+	".min.css",
+	"_generated.go",
+}
+
+// generatedFilenames are lockfiles and other files that are generated by
+// tools rather than written by hand.
+var generatedFilenames = map[string]bool{
+	"Cargo.lock":        true,
+	"Gemfile.lock":      true,
+	"go.sum":            true,
+	"package-lock.json": true,
+	"yarn.lock":         true,
+}
+
+// generatedMarkerRegex matches the marker comment used by generated Go code,
+// and commonly by other languages too.
+var generatedMarkerRegex = regexp.MustCompile(`^\s*(//|#)\s*Code generated .* DO NOT EDIT\.?\s*$`)
+
+// vendoredDirectories are directories that contain vendored code.
+var vendoredDirectories = []string{"vendor", "node_modules", "third_party"}
+
+// DetectLanguage returns the language of the file with the given name. The
+// first line of the file is used to detect the interpreter of scripts.
+func DetectLanguage(name string, firstLine string) string {
+	base := path.Base(name)
+	if language, ok := languagesByFilename[base]; ok {
+		return language
+	}
+
+	if language, ok := languagesByExtension[strings.ToLower(path.Ext(base))]; ok {
+		return language
+	}
+
+	if strings.HasPrefix(firstLine, "#!") {
+		fields := strings.Fields(strings.TrimPrefix(firstLine, "#!"))
+		if len(fields) > 0 {
+			interpreter := path.Base(fields[0])
+			if interpreter == "env" && len(fields) > 1 {
+				interpreter = fields[1]
+			}
+
+			return languagesByInterpreter[interpreter]
+		}
+	}
+
+	return ""
+}
+
+// annotateFile sets the language, generated, and vendored fields of the
+// given file based on its name and contents.
+func annotateFile(f *File) {
+	firstLine := ""
+	if len(f.Right) > 0 && f.Right[0].LineNo == 1 {
+		firstLine = f.Right[0].Content
+	}
+
+	f.Language = DetectLanguage(f.path(), firstLine)
+	f.Generated = isGenerated(f.path(), f.Right)
+	f.Vendored = isVendored(f.path())
+}
+
+func isGenerated(name string, right []Line) bool {
+	if generatedFilenames[path.Base(name)] {
+		return true
+	}
+
+	for _, suffix := range generatedSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+
+	for _, line := range right {
+		if generatedMarkerRegex.MatchString(line.Content) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isVendored(name string) bool {
+	for _, dir := range vendoredDirectories {
+		if strings.HasPrefix(name, dir+"/") || strings.Contains(name, "/"+dir+"/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ApplyAttributes annotates every file in the diff with the gitattributes that
+// apply to it. The linguist-generated, linguist-vendored, and
+// linguist-language attributes override the detected values.
+func (d *Diff) ApplyAttributes(attributes *gitattributes.Attributes) {
+	for key, file := range d.Files {
+		attrs := attributes.For(file.path())
+		if len(attrs) == 0 {
+			continue
+		}
+
+		file.Attributes = attrs
+
+		if value, ok := attrs["linguist-generated"]; ok {
+			file.Generated = value != "false"
+		}
+		if value, ok := attrs["linguist-vendored"]; ok {
+			file.Vendored = value != "false"
+		}
+		if value, ok := attrs["linguist-language"]; ok && value != "true" && value != "false" {
+			file.Language = value
+		}
+
+		d.Files[key] = file
+	}
+
+	// Languages may have changed
+	d.Stats = statsForFiles(d.Files)
+}
+
+// WithoutGenerated returns a copy of the diff that excludes generated and
+// vendored files.
+func (d Diff) WithoutGenerated() Diff {
+	files := make([]File, 0, len(d.Files))
+	for _, file := range d.Files {
+		if file.Generated || file.Vendored {
+			continue
+		}
+
+		files = append(files, file)
+	}
+	sort.Slice(files, func(a, b int) bool { return files[a].Name < files[b].Name })
+
+	// Lines were already marked as moved when the diff was built
+	filtered := newDiff(files, diffOptions{})
+	filtered.Warnings = d.Warnings
+
+	return filtered
+}
@@ -0,0 +1,85 @@
+package manifest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/blakewilliams/manifest/pkg/gitattributes"
+	"github.com/stretchr/testify/require"
+)
+
+var generatedDiff = `
+diff --git a/rpc/service.pb.go b/rpc/service.pb.go
+new file mode 100644
+index 0000000..e69de29
+--- /dev/null
++++ b/rpc/service.pb.go
+@@ -0,0 +1,2 @@
++// Code generated by protoc-gen-go. DO NOT EDIT.
++package rpc
+diff --git a/script/deploy b/script/deploy
+new file mode 100644
+index 0000000..e69de29
+--- /dev/null
++++ b/script/deploy
+@@ -0,0 +1,2 @@
++#!/usr/bin/env ruby
++puts "deploying"
+diff --git a/schema/types.go b/schema/types.go
+new file mode 100644
+index 0000000..e69de29
+--- /dev/null
++++ b/schema/types.go
+@@ -0,0 +1,1 @@
++package schema
+diff --git a/vendor/github.com/foo/foo.go b/vendor/github.com/foo/foo.go
+new file mode 100644
+index 0000000..e69de29
+--- /dev/null
++++ b/vendor/github.com/foo/foo.go
+@@ -0,0 +1,1 @@
++package foo`
+
+func TestNewDiff_Annotations(t *testing.T) {
+	diff, err := NewDiff(strings.NewReader(generatedDiff))
+	require.NoError(t, err)
+
+	proto := diff.Files["rpc/service.pb.go"]
+	require.Equal(t, "Go", proto.Language)
+	require.True(t, proto.Generated)
+	require.False(t, proto.Vendored)
+
+	deploy := diff.Files["script/deploy"]
+	require.Equal(t, "Ruby", deploy.Language)
+	require.False(t, deploy.Generated)
+
+	vendored := diff.Files["vendor/github.com/foo/foo.go"]
+	require.True(t, vendored.Vendored)
+}
+
+func TestDiff_ApplyAttributes(t *testing.T) {
+	diff, err := NewDiff(strings.NewReader(generatedDiff))
+	require.NoError(t, err)
+
+	attributes, err := gitattributes.Parse(strings.NewReader("schema/*.go linguist-generated\n*.pb.go -linguist-generated\nscript/* linguist-language=Crystal -diff\n"))
+	require.NoError(t, err)
+
+	diff.ApplyAttributes(attributes)
+
+	require.True(t, diff.Files["schema/types.go"].Generated)
+	require.False(t, diff.Files["rpc/service.pb.go"].Generated)
+	require.Equal(t, "Crystal", diff.Files["script/deploy"].Language)
+	require.Equal(t, map[string]string{"linguist-language": "Crystal", "diff": "false"}, diff.Files["script/deploy"].Attributes)
+
+	filtered := diff.WithoutGenerated()
+	require.Len(t, filtered.Files, 2)
+	require.Equal(t, []string{"rpc/service.pb.go", "script/deploy"}, filtered.NewFiles)
+}
+
+func TestDetectLanguage(t *testing.T) {
+	require.Equal(t, "Go", DetectLanguage("main.go", ""))
+	require.Equal(t, "Ruby", DetectLanguage("Gemfile", ""))
+	require.Equal(t, "Shell", DetectLanguage("script/ci", "#!/bin/sh\n"))
+	require.Equal(t, "Python", DetectLanguage("bin/tool", "#!/usr/bin/env python3\n"))
+	require.Equal(t, "", DetectLanguage("LICENSE", "MIT License\n"))
+}
@@ -0,0 +1,171 @@
+package manifest
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// DiffOption configures the limits used when parsing a diff.
+type DiffOption func(*diffOptions)
+
+type diffOptions struct {
+	maxDiffBytes  int64
+	maxFileLines  int
+	maxLineLength int
+	// reindentedMoves ignores leading and trailing whitespace when detecting
+	// moved lines
+	reindentedMoves bool
+}
+
+// WithMaxDiffBytes limits how much of the diff is read. Files past the limit
+// are skipped and a warning is added to the diff.
+func WithMaxDiffBytes(n int64) DiffOption {
+	return func(o *diffOptions) { o.maxDiffBytes = n }
+}
+
+// WithMaxFileLines limits the number of added and deleted lines kept for each
+// file. Files with more changed lines are truncated.
+func WithMaxFileLines(n int) DiffOption {
+	return func(o *diffOptions) { o.maxFileLines = n }
+}
+
+// WithMaxLineLength limits the length in bytes of each changed line. Files with
+// longer lines are truncated.
+func WithMaxLineLength(n int) DiffOption {
+	return func(o *diffOptions) { o.maxLineLength = n }
+}
+
+// WithReindentedMoves ignores leading and trailing whitespace when detecting
+// moved lines, so code that was moved and re-indented is still marked as moved.
+func WithReindentedMoves() DiffOption {
+	return func(o *diffOptions) { o.reindentedMoves = true }
+}
+
+func newDiffOptions(opts []DiffOption) diffOptions {
+	var options diffOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	return options
+}
+
+// readDiff reads the diff up to maxBytes, returning true if the diff was
+// longer than the limit.
+func readDiff(r io.Reader, maxBytes int64) ([]byte, bool, error) {
+	if maxBytes <= 0 {
+		content, err := io.ReadAll(r)
+		return content, false, err
+	}
+
+	content, err := io.ReadAll(io.LimitReader(r, maxBytes+1))
+	if err != nil {
+		return nil, false, err
+	}
+	if int64(len(content)) > maxBytes {
+		return content[:maxBytes], true, nil
+	}
+
+	return content, false, nil
+}
+
+// splitDiff splits a diff into one chunk per file so that each file can be
+// parsed on its own. Anything before the first file, like the commit message
+// from `git show`, is discarded. Diffs without `diff --git` headers are
+// returned as a single chunk.
+func splitDiff(content []byte) []string {
+	chunks := make([]string, 0)
+	var current strings.Builder
+	seenHeader := false
+
+	scanner := bufio.NewReader(bytes.NewReader(content))
+	for {
+		line, err := scanner.ReadString('\n')
+		if strings.HasPrefix(line, "diff --git ") {
+			if seenHeader {
+				chunks = append(chunks, current.String())
+			}
+			current.Reset()
+			seenHeader = true
+		}
+		current.WriteString(line)
+
+		if err != nil {
+			break
+		}
+	}
+
+	if !seenHeader {
+		if strings.TrimSpace(current.String()) == "" {
+			return chunks
+		}
+		return []string{current.String()}
+	}
+
+	return append(chunks, current.String())
+}
+
+// chunkName returns a description of the file in a diff chunk for warnings.
+func chunkName(chunk string) string {
+	header, _, _ := strings.Cut(chunk, "\n")
+	if name, ok := strings.CutPrefix(header, "diff --git "); ok {
+		return name
+	}
+
+	return "diff"
+}
+
+// truncateFile applies the file and line limits to the given file, marking it
+// as truncated if any lines were dropped or shortened.
+func truncateFile(f *File, options diffOptions) {
+	if options.maxFileLines > 0 {
+		if len(f.Left) > options.maxFileLines {
+			f.Left = f.Left[:options.maxFileLines]
+			f.Truncated = true
+		}
+		if len(f.Right) > options.maxFileLines {
+			f.Right = f.Right[:options.maxFileLines]
+			f.Truncated = true
+		}
+	}
+
+	if options.maxLineLength > 0 {
+		for _, lines := range [][]Line{f.Left, f.Right} {
+			for n, line := range lines {
+				if len(line.Content) > options.maxLineLength {
+					lines[n].Content = truncateString(line.Content, options.maxLineLength)
+					f.Truncated = true
+				}
+			}
+		}
+	}
+
+	// The lines of the hunks are only kept when none of them exceed the limits
+	tooLong := func(line FragmentLine) bool {
+		return options.maxLineLength > 0 && len(line.Content) > options.maxLineLength
+	}
+	for n, fragment := range f.Fragments {
+		if f.Truncated || slices.ContainsFunc(fragment.Lines, tooLong) {
+			f.Fragments[n].Lines = nil
+		}
+	}
+}
+
+// truncateString shortens s to at most n bytes without splitting a UTF-8
+// character.
+func truncateString(s string, n int) string {
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+
+	return s[:n]
+}
+
+func diffSizeWarning(maxBytes int64) string {
+	return fmt.Sprintf("diff is larger than %d bytes, the remaining files were skipped", maxBytes)
+}
@@ -0,0 +1,120 @@
+package manifest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var malformedDiff = `
+diff --git a/broken.txt b/broken.txt
+index abc1234..def5678 100644
+--- a/broken.txt
++++ b/broken.txt
+@@ -1,3 +1,3 @@
+ one
+-two
+diff --git a/README.md b/README.md
+index abc1234..def5678 100644
+--- a/README.md
++++ b/README.md
+@@ -1,2 +1,4 @@
+ # Manifest
++
++Lint your diffs.
+ end
+`
+
+var largeDiff = `diff --git a/a.txt b/a.txt
+new file mode 100644
+index 0000000..def5678
+--- /dev/null
++++ b/a.txt
+@@ -0,0 +1,4 @@
++short
++this line is too long
++three
++four
+diff --git a/b.txt b/b.txt
+new file mode 100644
+index 0000000..def5678
+--- /dev/null
++++ b/b.txt
+@@ -0,0 +1 @@
++b
+`
+
+func TestNewDiff_SkipsMalformedFiles(t *testing.T) {
+	diff, err := NewDiff(strings.NewReader(malformedDiff))
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"README.md"}, diff.ChangedFiles)
+	require.Len(t, diff.Warnings, 1)
+	require.Contains(t, diff.Warnings[0], "skipped a/broken.txt b/broken.txt")
+}
+
+func TestNewDiff_InvalidDiff(t *testing.T) {
+	_, err := NewDiff(strings.NewReader("diff --git a/x b/x\n@@ -1 +1 @@\nnope\n"))
+	require.Error(t, err)
+}
+
+func TestNewDiff_MaxFileLines(t *testing.T) {
+	diff, err := NewDiff(strings.NewReader(largeDiff), WithMaxFileLines(2))
+	require.NoError(t, err)
+
+	file := diff.Files["a.txt"]
+	require.True(t, file.Truncated)
+	require.Len(t, file.Right, 2)
+	require.Equal(t, 4, file.Additions)
+	require.Equal(t, 5, diff.Stats.Additions)
+	require.Len(t, file.Fragments, 1)
+	require.Nil(t, file.Fragments[0].Lines)
+
+	require.False(t, diff.Files["b.txt"].Truncated)
+	require.NotEmpty(t, diff.Files["b.txt"].Fragments[0].Lines)
+}
+
+func TestNewDiff_MaxLineLength(t *testing.T) {
+	diff, err := NewDiff(strings.NewReader(largeDiff), WithMaxLineLength(8))
+	require.NoError(t, err)
+
+	file := diff.Files["a.txt"]
+	require.True(t, file.Truncated)
+	require.Equal(t, "short\n", file.Right[0].Content)
+	require.Equal(t, "this lin", file.Right[1].Content)
+}
+
+func TestNewDiff_MaxDiffBytes(t *testing.T) {
+	diff, err := NewDiff(strings.NewReader(largeDiff), WithMaxDiffBytes(int64(len(largeDiff)-5)))
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"a.txt"}, diff.NewFiles)
+	require.Len(t, diff.Warnings, 1)
+	require.Contains(t, diff.Warnings[0], "remaining files were skipped")
+}
+
+func TestTruncateString(t *testing.T) {
+	require.Equal(t, "h", truncateString("héllo", 2))
+	require.Equal(t, "hé", truncateString("héllo", 3))
+}
+
+func TestPerform_ImportFile(t *testing.T) {
+	formatter := &recordingFormatter{}
+	config := &Configuration{
+		Concurrency:         2,
+		Formatter:           formatter,
+		ImportFileThreshold: 1,
+		Checkers: map[string]Checker{
+			// Both stdin and the import file should contain the import
+			"stdin": {Command: `grep -q README && echo '{"comments":[{"text":"stdin","severity":"Info"}]}'`},
+			"file":  {Command: `grep -q README "$MANIFEST_IMPORT_FILE" && echo '{"comments":[{"text":"file","severity":"Info"}]}'`},
+		},
+	}
+
+	check, err := NewCheck(config, strings.NewReader(newFile))
+	require.NoError(t, err)
+
+	require.NoError(t, check.Perform())
+	require.Len(t, formatter.results, 2)
+}
@@ -3,6 +3,7 @@ package manifest
 import (
 	"fmt"
 	"io"
+	"strings"
 
 	"github.com/bluekeyes/go-gitdiff/gitdiff"
 )
@@ -22,6 +23,14 @@ type Import struct {
 
 	// Diff is the parsed changes for this diff
 	Diff Diff `json:"diff"`
+
+	// Commits is the list of commits that make up the diff, oldest first.
+	// It's only present when the commit range or pull request is known.
+	Commits []Commit `json:"commits,omitempty"`
+
+	// Commit is the commit being checked when running in per-commit mode. In
+	// that mode Diff only includes the changes introduced by this commit.
+	Commit *Commit `json:"commit,omitempty"`
 }
 
 type Pull struct {
@@ -57,6 +66,16 @@ type Diff struct {
 
 	// Files is a mapping of file names to the file contents
 	Files map[string]File `json:"files"`
+
+	// Owners is the set of CODEOWNERS owners touched by the diff.
+	Owners []string `json:"owners,omitempty"`
+
+	// Stats summarizes the size of the diff.
+	Stats DiffStats `json:"stats"`
+
+	// Warnings are problems found while parsing the diff, like files that
+	// couldn't be parsed and were skipped.
+	Warnings []string `json:"warnings,omitempty"`
 }
 
 type DiffOperation string
@@ -77,10 +96,42 @@ type File struct {
 	Name string `json:"new_name"`
 	// OldName is the old name of the file, if it was renamed
 	OldName string `json:"old_name"`
+	// Similarity is the similarity percentage between the old and new file
+	// for renames and copies, when detected by git using -M or -C.
+	Similarity int `json:"similarity,omitempty"`
 
 	Left  []Line `json:"left"`
 	Right []Line `json:"right"`
 
+	// Additions is the number of added lines in the file
+	Additions int `json:"additions"`
+	// Deletions is the number of deleted lines in the file
+	Deletions int `json:"deletions"`
+	// Hunks is the number of hunks in the file
+	Hunks int `json:"hunks"`
+	// Fragments are the file's hunks, including their unchanged context lines.
+	// They're only known for files parsed from a diff and aren't passed to
+	// checkers.
+	Fragments []Fragment `json:"-"`
+
+	// Language is the detected language of the file, like "Go" or "Ruby".
+	// It's empty when the language can't be detected.
+	Language string `json:"language,omitempty"`
+	// Generated is true if the file contains generated code, either based on
+	// its name and contents or the linguist-generated attribute.
+	Generated bool `json:"generated"`
+	// Vendored is true if the file contains vendored code, either based on
+	// its path or the linguist-vendored attribute.
+	Vendored bool `json:"vendored"`
+	// Attributes are the gitattributes that apply to the file. Set attributes
+	// have the value "true" and unset attributes have the value "false".
+	Attributes map[string]string `json:"attributes,omitempty"`
+	// Owners are the teams and users that own the file based on CODEOWNERS.
+	Owners []string `json:"owners,omitempty"`
+	// Truncated is true if some of the file's changed lines were dropped or
+	// shortened because they exceeded the configured limits.
+	Truncated bool `json:"truncated,omitempty"`
+
 	// TODO include mode changes
 }
 
@@ -88,30 +139,150 @@ type File struct {
 type Line struct {
 	LineNo  uint   `json:"lineno"`
 	Content string `json:"content"`
+	// Moved is true if the line is part of a block of code that was deleted
+	// in one place and added in another, rather than a new change.
+	Moved bool `json:"moved,omitempty"`
+}
+
+// NewLineNo returns the line number in the new version of the file for a line
+// in the old version of the file. It returns false if the line was deleted.
+func (f File) NewLineNo(oldLineNo uint) (uint, bool) {
+	return mapLineNo(oldLineNo, f.Left, f.Right)
 }
 
-// NewDiff returns a new diff that can be used by plugins
-func NewDiff(f io.Reader) (Diff, error) {
-	files, _, err := gitdiff.Parse(f)
+// OldLineNo returns the line number in the old version of the file for a line
+// in the new version of the file. It returns false if the line was added.
+func (f File) OldLineNo(newLineNo uint) (uint, bool) {
+	return mapLineNo(newLineNo, f.Right, f.Left)
+}
+
+// mapLineNo maps an unchanged line from one side of the diff to the other by
+// removing the lines only present on the from side before it, then shifting
+// it past the lines only present on the to side.
+func mapLineNo(lineNo uint, from []Line, to []Line) (uint, bool) {
+	removed := uint(0)
+	for _, l := range from {
+		if l.LineNo == lineNo {
+			return 0, false
+		}
+		if l.LineNo < lineNo {
+			removed++
+		}
+	}
+
+	mapped := lineNo - removed
+	for _, l := range to {
+		if l.LineNo > mapped {
+			break
+		}
+		mapped++
+	}
 
+	return mapped, true
+}
+
+// NewDiff returns a new diff that can be used by plugins. Files that can't be
+// parsed are skipped and reported in the diff's warnings.
+func NewDiff(f io.Reader, opts ...DiffOption) (Diff, error) {
+	options := newDiffOptions(opts)
+
+	content, truncated, err := readDiff(f, options.maxDiffBytes)
 	if err != nil {
-		return Diff{}, fmt.Errorf("failed to parse git diff: %w", err)
+		return Diff{}, fmt.Errorf("failed to read git diff: %w", err)
 	}
 
-	diff := &Diff{
-		ChangedFiles: make([]string, 0),
-		DeletedFiles: make([]string, 0),
-		RenamedFiles: make([]string, 0),
-		CopiedFiles:  make([]string, 0),
-		NewFiles:     make([]string, 0),
-		Files:        make(map[string]File, len(files)),
+	chunks := splitDiff(content)
+	warnings := make([]string, 0)
+	if truncated {
+		// The last file was cut off part way through
+		if len(chunks) > 0 {
+			chunks = chunks[:len(chunks)-1]
+		}
+		warnings = append(warnings, diffSizeWarning(options.maxDiffBytes))
+	}
+
+	parsed, skipped, parseErr := parseChunks(chunks)
+	warnings = append(warnings, skipped...)
+
+	// Input that isn't a diff at all is still an error
+	if len(parsed) == 0 && parseErr != nil {
+		return Diff{}, fmt.Errorf("failed to parse git diff: %w", parseErr)
+	}
+
+	diff := newDiff(filesFromGitDiff(parsed, options), options)
+	if len(warnings) > 0 {
+		diff.Warnings = warnings
 	}
 
+	return diff, nil
+}
+
+// parseChunks parses the files of every chunk of a diff. Chunks that can't be
+// parsed are skipped, returning a warning for each along with the last error.
+func parseChunks(chunks []string) ([]*gitdiff.File, []string, error) {
+	files := make([]*gitdiff.File, 0, len(chunks))
+	warnings := make([]string, 0)
+	var parseErr error
+	for _, chunk := range chunks {
+		parsed, _, err := gitdiff.Parse(strings.NewReader(chunk))
+		if err != nil {
+			parseErr = err
+			warnings = append(warnings, fmt.Sprintf("skipped %s: %s", chunkName(chunk), err))
+			continue
+		}
+
+		files = append(files, parsed...)
+	}
+
+	return files, warnings, parseErr
+}
+
+// Fragment is a single hunk of a file's diff.
+type Fragment struct {
+	// OldPosition is the first line of the hunk in the old file
+	OldPosition uint
+	// OldLines is the number of lines of the old file in the hunk
+	OldLines uint
+	// NewPosition is the first line of the hunk in the new file
+	NewPosition uint
+	// NewLines is the number of lines of the new file in the hunk
+	NewLines uint
+	// Comment is the text following the hunk header, usually the enclosing
+	// function or class
+	Comment string
+	// Lines are the lines of the hunk in order. They're dropped when the file
+	// is truncated.
+	Lines []FragmentLine
+}
+
+// FragmentLine is a deleted, added, or unchanged context line of a hunk.
+type FragmentLine struct {
+	// OldLineNo is the line number in the old file, zero for added lines
+	OldLineNo uint
+	// NewLineNo is the line number in the new file, zero for deleted lines
+	NewLineNo uint
+	Content   string
+}
+
+// filesFromGitDiff converts the parsed gitdiff files into files that can be
+// used by plugins.
+func filesFromGitDiff(files []*gitdiff.File, options diffOptions) []File {
+	converted := make([]File, 0, len(files))
+
 	for _, file := range files {
 		leftLines := make([]Line, 0)
 		rightLines := make([]Line, 0)
+		fragments := make([]Fragment, 0, len(file.TextFragments))
 
 		for _, fragment := range file.TextFragments {
+			converted := Fragment{
+				OldPosition: uint(fragment.OldPosition),
+				OldLines:    uint(fragment.OldLines),
+				NewPosition: uint(fragment.NewPosition),
+				NewLines:    uint(fragment.NewLines),
+				Comment:     fragment.Comment,
+				Lines:       make([]FragmentLine, 0, len(fragment.Lines)),
+			}
 			leftStart := fragment.OldPosition
 			rightStart := fragment.NewPosition
 
@@ -122,48 +293,84 @@ func NewDiff(f io.Reader) (Diff, error) {
 						LineNo:  uint(leftStart),
 						Content: line.Line,
 					})
+					converted.Lines = append(converted.Lines, FragmentLine{OldLineNo: uint(leftStart), Content: line.Line})
 					leftStart++
 				case gitdiff.OpAdd:
 					rightLines = append(rightLines, Line{
 						LineNo:  uint(rightStart),
 						Content: line.Line,
 					})
+					converted.Lines = append(converted.Lines, FragmentLine{NewLineNo: uint(rightStart), Content: line.Line})
 					rightStart++
 				default:
+					converted.Lines = append(converted.Lines, FragmentLine{OldLineNo: uint(leftStart), NewLineNo: uint(rightStart), Content: line.Line})
 					leftStart++
 					rightStart++
 				}
 			}
+
+			fragments = append(fragments, converted)
+		}
+
+		f := File{
+			Name:       file.NewName,
+			OldName:    file.OldName,
+			Operation:  operationForFile(file),
+			Left:       leftLines,
+			Right:      rightLines,
+			Additions:  len(rightLines),
+			Deletions:  len(leftLines),
+			Hunks:      len(file.TextFragments),
+			Fragments:  fragments,
+			Similarity: file.Score,
 		}
+		annotateFile(&f)
+		truncateFile(&f, options)
 
+		converted = append(converted, f)
+	}
+
+	return converted
+}
+
+// newDiff builds a diff out of the given files, populating the file lists
+// based on each file's operation.
+func newDiff(files []File, options diffOptions) Diff {
+	diff := &Diff{
+		ChangedFiles: make([]string, 0),
+		DeletedFiles: make([]string, 0),
+		RenamedFiles: make([]string, 0),
+		CopiedFiles:  make([]string, 0),
+		NewFiles:     make([]string, 0),
+		Files:        make(map[string]File, len(files)),
+	}
+
+	for _, file := range files {
 		// Add the file to the mapping
 		name := file.OldName
 		if name == "" {
-			name = file.NewName
-		}
-		diff.Files[name] = File{
-			Name:      file.NewName,
-			OldName:   file.OldName,
-			Operation: operationForFile(file),
-			Left:      leftLines,
-			Right:     rightLines,
+			name = file.Name
 		}
+		diff.Files[name] = file
 
-		if file.IsNew {
-			diff.NewFiles = append(diff.NewFiles, file.NewName)
-		} else if file.IsDelete {
+		switch file.Operation {
+		case DiffOperationNew:
+			diff.NewFiles = append(diff.NewFiles, file.Name)
+		case DiffOperationDelete:
 			diff.DeletedFiles = append(diff.DeletedFiles, file.OldName)
-		} else if file.IsRename {
+		case DiffOperationRename:
 			diff.RenamedFiles = append(diff.RenamedFiles, file.OldName)
-		} else if file.IsCopy {
+		case DiffOperationCopy:
 			diff.CopiedFiles = append(diff.CopiedFiles, file.OldName)
-		} else {
+		default:
 			diff.ChangedFiles = append(diff.ChangedFiles, file.OldName)
 		}
-
 	}
+	detectMovedLines(files, options.reindentedMoves)
+	diff.Owners = ownersForFiles(diff.Files)
+	diff.Stats = statsForFiles(diff.Files)
 
-	return *diff, nil
+	return *diff
 }
 
 func operationForFile(f *gitdiff.File) DiffOperation {
@@ -0,0 +1,181 @@
+package manifest
+
+import (
+	"strings"
+)
+
+// minMovedLines is the minimum number of consecutive lines that have to be
+// deleted in one place and added in another to be considered moved.
+const minMovedLines = 3
+
+// lineRef references a single line in a run of consecutive changed lines.
+type lineRef struct {
+	run int
+	idx int
+}
+
+// changeRun is a run of consecutive changed lines on one side of a file.
+type changeRun struct {
+	// file is the index of the file the run belongs to
+	file int
+	// hunk is the index of the hunk containing the run, or -1 if the file's
+	// hunks aren't known
+	hunk  int
+	lines []Line
+}
+
+// inPlace returns true if both runs could be part of the same change, like a
+// block that was edited or re-indented where it is.
+func (r changeRun) inPlace(other changeRun) bool {
+	if r.file != other.file {
+		return false
+	}
+
+	// Without hunks, a change within the file can't be told apart from a move
+	return r.hunk < 0 || other.hunk < 0 || r.hunk == other.hunk
+}
+
+// detectMovedLines marks blocks of lines that were deleted in one hunk and
+// added in another hunk or file as moved. Lines have to match exactly unless
+// ignoreWhitespace is set, in which case leading and trailing whitespace is
+// ignored so re-indented code is still detected.
+func detectMovedLines(files []File, ignoreWhitespace bool) {
+	normalize := func(content string) string {
+		if ignoreWhitespace {
+			return strings.TrimSpace(content)
+		}
+		return content
+	}
+
+	deletedRuns := make([]changeRun, 0)
+	addedRuns := make([]changeRun, 0)
+	for n, file := range files {
+		for _, run := range splitRuns(file.Left) {
+			deletedRuns = append(deletedRuns, changeRun{file: n, hunk: file.hunkOf(true, run[0].LineNo), lines: run})
+		}
+		for _, run := range splitRuns(file.Right) {
+			addedRuns = append(addedRuns, changeRun{file: n, hunk: file.hunkOf(false, run[0].LineNo), lines: run})
+		}
+	}
+
+	// Only lines starting a long enough block of added lines are candidates
+	added := make(map[string][]lineRef)
+	for r, run := range addedRuns {
+		for i := 0; i+minMovedLines <= len(run.lines); i++ {
+			line := run.lines[i]
+			if strings.TrimSpace(line.Content) == "" {
+				continue
+			}
+			content := normalize(line.Content)
+			added[content] = append(added[content], lineRef{run: r, idx: i})
+		}
+	}
+
+	for _, deletedRun := range deletedRuns {
+		deleted := deletedRun.lines
+		for i := 0; i+minMovedLines <= len(deleted); {
+			if deleted[i].Moved {
+				i++
+				continue
+			}
+
+			// Candidates that were moved since they were indexed are dropped
+			// so that repeated lines aren't scanned over and over
+			content := normalize(deleted[i].Content)
+			candidates := added[content][:0]
+			best, bestRef := 0, lineRef{}
+			for _, ref := range added[content] {
+				addedRun := addedRuns[ref.run]
+				if addedRun.lines[ref.idx].Moved {
+					continue
+				}
+				candidates = append(candidates, ref)
+				if best == len(deleted)-i || deletedRun.inPlace(addedRun) {
+					continue
+				}
+
+				length := 0
+				for i+length < len(deleted) && ref.idx+length < len(addedRun.lines) &&
+					!addedRun.lines[ref.idx+length].Moved &&
+					normalize(deleted[i+length].Content) == normalize(addedRun.lines[ref.idx+length].Content) {
+					length++
+				}
+				if length > best {
+					best, bestRef = length, ref
+				}
+			}
+			added[content] = candidates
+
+			if best < minMovedLines {
+				i++
+				continue
+			}
+
+			// The whole block is moved, so matching resumes after it
+			addedRun := addedRuns[bestRef.run].lines
+			for n := 0; n < best; n++ {
+				deleted[i+n].Moved = true
+				addedRun[bestRef.idx+n].Moved = true
+			}
+			i += best
+		}
+	}
+}
+
+// hunkOf returns the index of the hunk containing the given line on the left
+// or right side of the file, or -1 if the file's hunks aren't known.
+func (f File) hunkOf(left bool, lineNo uint) int {
+	for n, fragment := range f.Fragments {
+		start, count := fragment.NewPosition, fragment.NewLines
+		if left {
+			start, count = fragment.OldPosition, fragment.OldLines
+		}
+
+		if lineNo >= start && lineNo < start+count {
+			return n
+		}
+	}
+
+	return -1
+}
+
+// splitRuns splits the given lines into runs of consecutive line numbers. The
+// runs share the backing array of lines so they can be modified in place.
+func splitRuns(lines []Line) [][]Line {
+	runs := make([][]Line, 0)
+
+	start := 0
+	for n := 1; n <= len(lines); n++ {
+		if n == len(lines) || lines[n-1].LineNo+1 != lines[n].LineNo {
+			runs = append(runs, lines[start:n])
+			start = n
+		}
+	}
+
+	return runs
+}
+
+// IsMovedLine returns true if the line on the given side ("LEFT" or "RIGHT")
+// of the file with the given new name was moved rather than changed.
+func (d Diff) IsMovedLine(name string, side string, lineNo uint) bool {
+	file, ok := d.FileByNewName(name)
+	if !ok {
+		file, ok = d.Files[name]
+		if !ok {
+			return false
+		}
+	}
+
+	lines := file.Right
+	if side == "LEFT" {
+		lines = file.Left
+	}
+
+	for _, line := range lines {
+		if line.LineNo == lineNo {
+			return line.Moved
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,204 @@
+package manifest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var movedDiff = `
+diff --git a/lib/a.rb b/lib/a.rb
+index abc1234..def5678 100644
+--- a/lib/a.rb
++++ b/lib/a.rb
+@@ -1,7 +1,3 @@
+ class A
+-  def greet(name)
+-    puts "Hello #{name}"
+-    name
+-  end
+-  def x; end
++  def y; end
+ end
+diff --git a/lib/b.rb b/lib/b.rb
+index abc1234..def5678 100644
+--- a/lib/b.rb
++++ b/lib/b.rb
+@@ -1,2 +1,8 @@
+ module B
++  class Greeter
++    def greet(name)
++      puts "Hello #{name}"
++      name
++    end
++  end
+ end
+diff --git a/lib/old.rb b/lib/new.rb
+similarity index 87%
+rename from lib/old.rb
+rename to lib/new.rb
+index abc1234..def5678 100644
+--- a/lib/old.rb
++++ b/lib/new.rb
+@@ -1,1 +1,1 @@
+-old
++new`
+
+func TestNewDiff_MovedLines(t *testing.T) {
+	diff, err := NewDiff(strings.NewReader(movedDiff), WithReindentedMoves())
+	require.NoError(t, err)
+
+	a := diff.Files["lib/a.rb"]
+	for _, line := range a.Left[:4] {
+		require.True(t, line.Moved, "expected %q to be moved", line.Content)
+	}
+	require.False(t, a.Left[4].Moved)
+	require.False(t, a.Right[0].Moved)
+
+	b := diff.Files["lib/b.rb"]
+	require.False(t, b.Right[0].Moved)
+	for _, line := range b.Right[1:5] {
+		require.True(t, line.Moved, "expected %q to be moved", line.Content)
+	}
+	require.False(t, b.Right[5].Moved)
+
+	require.True(t, diff.IsMovedLine("lib/b.rb", "RIGHT", 3))
+	require.True(t, diff.IsMovedLine("lib/a.rb", "LEFT", 2))
+	require.False(t, diff.IsMovedLine("lib/b.rb", "RIGHT", 2))
+
+	require.Equal(t, 87, diff.Files["lib/old.rb"].Similarity)
+
+	// The greet method was re-indented, so it only matches ignoring whitespace
+	diff, err = NewDiff(strings.NewReader(movedDiff))
+	require.NoError(t, err)
+	require.False(t, diff.IsMovedLine("lib/b.rb", "RIGHT", 3))
+	require.False(t, diff.IsMovedLine("lib/a.rb", "LEFT", 2))
+}
+
+func TestNewDiff_MovedLinesExactMatch(t *testing.T) {
+	diff, err := NewDiff(strings.NewReader(`diff --git a/lib/a.rb b/lib/a.rb
+index abc1234..def5678 100644
+--- a/lib/a.rb
++++ b/lib/a.rb
+@@ -1,4 +1,1 @@
+-one
+-two
+-three
+ end
+diff --git a/lib/b.rb b/lib/b.rb
+index abc1234..def5678 100644
+--- a/lib/b.rb
++++ b/lib/b.rb
+@@ -1,1 +1,4 @@
+ start
++one
++two
++three
+`))
+	require.NoError(t, err)
+
+	for lineNo := uint(1); lineNo <= 3; lineNo++ {
+		require.True(t, diff.IsMovedLine("lib/a.rb", "LEFT", lineNo))
+		require.True(t, diff.IsMovedLine("lib/b.rb", "RIGHT", lineNo+1))
+	}
+}
+
+func TestNewDiff_MovedLinesBetweenHunks(t *testing.T) {
+	diff, err := NewDiff(strings.NewReader(`diff --git a/lib/a.rb b/lib/a.rb
+index abc1234..def5678 100644
+--- a/lib/a.rb
++++ b/lib/a.rb
+@@ -1,4 +1,1 @@
+-one
+-two
+-three
+ a
+@@ -10,1 +7,4 @@
+ b
++one
++two
++three
+`))
+	require.NoError(t, err)
+
+	require.True(t, diff.IsMovedLine("lib/a.rb", "LEFT", 2))
+	require.True(t, diff.IsMovedLine("lib/a.rb", "RIGHT", 9))
+}
+
+func TestNewDiff_ReindentedInPlaceIsNotMoved(t *testing.T) {
+	diff, err := NewDiff(strings.NewReader(`diff --git a/lib/a.rb b/lib/a.rb
+index abc1234..def5678 100644
+--- a/lib/a.rb
++++ b/lib/a.rb
+@@ -1,5 +1,7 @@
+ class A
+-  def greet(name)
+-    puts "Hello #{name}"
+-    name
+-  end
++  class_methods do
++    def greet(name)
++      puts "Hello #{name}"
++      name
++    end
++  end
+ end
+`), WithReindentedMoves())
+	require.NoError(t, err)
+
+	file := diff.Files["lib/a.rb"]
+	for _, line := range append(file.Left, file.Right...) {
+		require.False(t, line.Moved, "expected %q not to be moved", line.Content)
+	}
+}
+
+func TestNewDiff_MovedLinesRepeated(t *testing.T) {
+	// The same line is deleted in blocks of three throughout one file and in
+	// a single block from another, then added in a single block to a third
+	const blocks = 2000
+	var diff strings.Builder
+	fmt.Fprintf(&diff, "diff --git a/lib/a.rb b/lib/a.rb\nindex abc1234..def5678 100644\n--- a/lib/a.rb\n+++ b/lib/a.rb\n@@ -1,%d +1,%d @@\n", blocks*4, blocks)
+	for n := 0; n < blocks; n++ {
+		diff.WriteString("-end\n-end\n-end\n def x\n")
+	}
+	fmt.Fprintf(&diff, "diff --git a/lib/c.rb b/lib/c.rb\nindex abc1234..def5678 100644\n--- a/lib/c.rb\n+++ b/lib/c.rb\n@@ -1,%d +1,1 @@\n start\n", blocks*3+1)
+	for n := 0; n < blocks*3; n++ {
+		diff.WriteString("-end\n")
+	}
+	fmt.Fprintf(&diff, "diff --git a/lib/b.rb b/lib/b.rb\nindex abc1234..def5678 100644\n--- a/lib/b.rb\n+++ b/lib/b.rb\n@@ -1,1 +1,%d @@\n start\n", blocks*6+1)
+	for n := 0; n < blocks*6; n++ {
+		diff.WriteString("+end\n")
+	}
+
+	parsed, err := NewDiff(strings.NewReader(diff.String()))
+	require.NoError(t, err)
+
+	for _, line := range append(parsed.Files["lib/a.rb"].Left, parsed.Files["lib/c.rb"].Left...) {
+		require.True(t, line.Moved, "expected line %d to be moved", line.LineNo)
+	}
+	for _, line := range parsed.Files["lib/b.rb"].Right {
+		require.True(t, line.Moved, "expected line %d to be moved", line.LineNo)
+	}
+}
+
+func TestPerform_IgnoreMoved(t *testing.T) {
+	config := &Configuration{
+		Concurrency: 1,
+		Formatter:   &recordingFormatter{},
+		IgnoreMoved: true,
+		// The greet method is re-indented when it's moved
+		DetectReindentedMoves: true,
+		Checkers: map[string]Checker{
+			"error": {Command: `echo '{"comments":[{"file":"lib/b.rb","line":3,"side":"RIGHT","text":"nope","severity":"Error"}]}'`},
+		},
+	}
+
+	check, err := NewCheck(config, strings.NewReader(movedDiff))
+	require.NoError(t, err)
+	require.NoError(t, check.Perform())
+
+	config.IgnoreMoved = false
+	require.ErrorIs(t, check.Perform(), ErrCheckReportedError)
+}
@@ -0,0 +1,41 @@
+package manifest
+
+import (
+	"sort"
+
+	"github.com/blakewilliams/manifest/pkg/codeowners"
+)
+
+// ApplyCodeOwners annotates every file in the diff with its owners and sets
+// the owners touched by the whole diff.
+func (d *Diff) ApplyCodeOwners(co *codeowners.CodeOwners) {
+	for key, file := range d.Files {
+		file.Owners = co.For(file.path())
+		d.Files[key] = file
+	}
+
+	d.Owners = ownersForFiles(d.Files)
+}
+
+// ownersForFiles returns the sorted, unique owners of the given files.
+func ownersForFiles(files map[string]File) []string {
+	seen := make(map[string]bool)
+	owners := make([]string, 0)
+
+	for _, file := range files {
+		for _, owner := range file.Owners {
+			if !seen[owner] {
+				seen[owner] = true
+				owners = append(owners, owner)
+			}
+		}
+	}
+
+	if len(owners) == 0 {
+		return nil
+	}
+
+	sort.Strings(owners)
+
+	return owners
+}
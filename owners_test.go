@@ -0,0 +1,25 @@
+package manifest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/blakewilliams/manifest/pkg/codeowners"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff_ApplyCodeOwners(t *testing.T) {
+	diff, err := NewDiff(strings.NewReader(generatedDiff))
+	require.NoError(t, err)
+
+	co, err := codeowners.Parse(strings.NewReader("* @acme/eng\n/rpc/ @acme/rpc\n/script/ @acme/ops @jane\n"))
+	require.NoError(t, err)
+
+	diff.ApplyCodeOwners(co)
+
+	require.Equal(t, []string{"@acme/rpc"}, diff.Files["rpc/service.pb.go"].Owners)
+	require.Equal(t, []string{"@acme/ops", "@jane"}, diff.Files["script/deploy"].Owners)
+	require.Equal(t, []string{"@acme/eng", "@acme/ops", "@acme/rpc", "@jane"}, diff.Owners)
+
+	require.Equal(t, []string{"@acme/eng", "@acme/ops", "@jane"}, diff.WithoutGenerated().Owners)
+}
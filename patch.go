@@ -0,0 +1,317 @@
+package manifest
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/bluekeyes/go-gitdiff/gitdiff"
+)
+
+// PatchSeries is a series of patches generated by `git format-patch`, either
+// as individual patch files or as a single mbox.
+type PatchSeries struct {
+	// CoverLetter is the commit metadata of the cover letter, if one is
+	// present.
+	CoverLetter *Commit
+	// Patches are the patches in the series, in order.
+	Patches []Patch
+	// Diff is the combined changes of every patch in the series.
+	Diff Diff
+}
+
+// Patch is a single patch in a PatchSeries.
+type Patch struct {
+	// Commit is the commit metadata parsed from the patch headers.
+	Commit Commit
+	// Diff is the changes introduced by this patch alone.
+	Diff Diff
+}
+
+// mboxSeparatorRegex matches the "From " line that starts every message in an
+// mbox, e.g. "From 3b18e512dba79e4c8300dd08aeb37f8e728b8dad Mon Sep 17 00:00:00 2001".
+var mboxSeparatorRegex = regexp.MustCompile(`^From \S+ +\w{3} \w{3} +\d+ \d{2}:\d{2}:\d{2} \d{4}`)
+
+// coverLetterRegex matches the subject prefix of a cover letter, e.g.
+// "[PATCH 0/3]" or "[RFC PATCH v2 00/12]".
+var coverLetterRegex = regexp.MustCompile(`\b0+/\d+\]`)
+
+// shortlogRegex matches the start of the shortlog that `git format-patch`
+// appends to the cover letter, e.g. "Jane Doe (3):".
+var shortlogRegex = regexp.MustCompile(`(?m)^\S.* \(\d+\):$`)
+
+// ParsePatchSeries parses an mbox or concatenated `git format-patch` output.
+// The options limit the size of each patch's files.
+func ParsePatchSeries(r io.Reader, opts ...DiffOption) (*PatchSeries, error) {
+	options := newDiffOptions(opts)
+
+	messages, err := splitMbox(r)
+	if err != nil {
+		return nil, fmt.Errorf("could not read patch series: %w", err)
+	}
+
+	if len(messages) == 0 {
+		return nil, fmt.Errorf("no patches found in patch series")
+	}
+
+	series := &PatchSeries{Patches: make([]Patch, 0, len(messages))}
+	patchFiles := make([][]File, 0, len(messages))
+	seriesWarnings := make([]string, 0)
+
+	for n, message := range messages {
+		files, preamble, err := gitdiff.Parse(strings.NewReader(message))
+		warnings := make([]string, 0)
+		if err != nil {
+			// Like NewDiff, the files that can't be parsed are skipped
+			var body string
+			preamble, body = splitPatch(message)
+			var parsed []*gitdiff.File
+			parsed, warnings, _ = parseChunks(splitDiff([]byte(body)))
+			if len(parsed) == 0 {
+				return nil, fmt.Errorf("failed to parse patch %d: %w", n+1, err)
+			}
+			files = parsed
+		}
+
+		header, err := gitdiff.ParsePatchHeader(preamble)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse headers of patch %d: %w", n+1, err)
+		}
+
+		commit := commitFromPatchHeader(header)
+
+		if len(files) == 0 {
+			if coverLetterRegex.MatchString(header.SubjectPrefix) {
+				commit.Body = coverLetterBlurb(commit.Body)
+				series.CoverLetter = &commit
+			}
+			continue
+		}
+
+		converted := filesFromGitDiff(files, options)
+		for _, file := range converted {
+			name := file.Name
+			if name == "" {
+				name = file.OldName
+			}
+			commit.Files = append(commit.Files, name)
+		}
+
+		diff := newDiff(converted, options)
+		for _, warning := range warnings {
+			diff.Warnings = append(diff.Warnings, warning)
+			seriesWarnings = append(seriesWarnings, fmt.Sprintf("patch %d: %s", n+1, warning))
+		}
+
+		patchFiles = append(patchFiles, converted)
+		series.Patches = append(series.Patches, Patch{Commit: commit, Diff: diff})
+	}
+
+	series.Diff = newDiff(combinePatchFiles(patchFiles), options)
+	if len(seriesWarnings) > 0 {
+		series.Diff.Warnings = seriesWarnings
+	}
+
+	return series, nil
+}
+
+// splitMbox splits the given mbox into its individual messages. Input that
+// doesn't start with an mbox separator is treated as a single message.
+func splitMbox(r io.Reader) ([]string, error) {
+	messages := make([]string, 0)
+	var current strings.Builder
+
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadString('\n')
+		if mboxSeparatorRegex.MatchString(line) && strings.TrimSpace(current.String()) != "" {
+			messages = append(messages, current.String())
+			current.Reset()
+		}
+		current.WriteString(line)
+
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if strings.TrimSpace(current.String()) != "" {
+		messages = append(messages, current.String())
+	}
+
+	return messages, nil
+}
+
+// splitPatch splits a patch into the headers and message preceding its diff,
+// and the diff itself.
+func splitPatch(message string) (string, string) {
+	if strings.HasPrefix(message, "diff --git ") {
+		return "", message
+	}
+	if idx := strings.Index(message, "\ndiff --git "); idx >= 0 {
+		return message[:idx+1], message[idx+1:]
+	}
+
+	return message, ""
+}
+
+// coverLetterBlurb returns the body of a cover letter without the shortlog,
+// diffstat, and signature generated by `git format-patch`.
+func coverLetterBlurb(body string) string {
+	if loc := shortlogRegex.FindStringIndex(body); loc != nil {
+		body = body[:loc[0]]
+	}
+
+	return strings.TrimSpace(body)
+}
+
+func commitFromPatchHeader(header *gitdiff.PatchHeader) Commit {
+	commit := Commit{
+		Sha:      header.SHA,
+		Subject:  header.Title,
+		Body:     header.Body,
+		Trailers: parseTrailers(header.Body),
+		Files:    make([]string, 0),
+	}
+
+	if header.Author != nil {
+		commit.Author = Signature{Name: header.Author.Name, Email: header.Author.Email, Date: header.AuthorDate}
+	}
+	if header.Committer != nil {
+		commit.Committer = Signature{Name: header.Committer.Name, Email: header.Committer.Email, Date: header.CommitterDate}
+	}
+
+	return commit
+}
+
+// combinedFile tracks the changes made to a single file across a series of
+// patches.
+type combinedFile struct {
+	file File
+	// history is every patch applied to the file, used to map lines in the
+	// current version back to the original version.
+	history []File
+}
+
+// combinePatchFiles combines the files of each patch in a series into the
+// files of a single diff from the original version to the final version.
+func combinePatchFiles(patches [][]File) []File {
+	// byName tracks each file by its name after the most recently applied
+	// patch. order preserves the order in which files were first seen.
+	byName := make(map[string]*combinedFile)
+	order := make([]*combinedFile, 0)
+
+	for _, files := range patches {
+		for _, pf := range files {
+			currentName := pf.OldName
+			if currentName == "" {
+				currentName = pf.Name
+			}
+
+			cf, ok := byName[currentName]
+			if !ok || pf.Operation == DiffOperationNew || pf.Operation == DiffOperationCopy {
+				cf = &combinedFile{file: File{
+					Operation: pf.Operation,
+					Name:      pf.Name,
+					OldName:   pf.OldName,
+					Left:      make([]Line, 0),
+					Right:     make([]Line, 0),
+				}}
+				order = append(order, cf)
+			}
+
+			cf.apply(pf)
+
+			if pf.Operation != DiffOperationCopy {
+				delete(byName, currentName)
+			}
+			if pf.Operation != DiffOperationDelete {
+				byName[pf.Name] = cf
+			}
+		}
+	}
+
+	combined := make([]File, 0, len(order))
+	for _, cf := range order {
+		// Files created and deleted within the series don't show up in the
+		// combined diff.
+		if cf.file.Operation == DiffOperationNew && cf.file.Name == "" {
+			continue
+		}
+
+		sort.Slice(cf.file.Left, func(a, b int) bool { return cf.file.Left[a].LineNo < cf.file.Left[b].LineNo })
+		sort.Slice(cf.file.Right, func(a, b int) bool { return cf.file.Right[a].LineNo < cf.file.Right[b].LineNo })
+		annotateFile(&cf.file)
+		// The hunks of the combined file can't be known without its contents,
+		// so they're estimated from the changed lines.
+		cf.file.Hunks = max(countRuns(cf.file.Left), countRuns(cf.file.Right))
+		cf.file.Additions = len(cf.file.Right)
+		cf.file.Deletions = len(cf.file.Left)
+
+		combined = append(combined, cf.file)
+	}
+
+	return combined
+}
+
+// apply applies the changes of a single patch to the combined file.
+func (cf *combinedFile) apply(pf File) {
+	for _, deleted := range pf.Left {
+		// Lines added by a previous patch and deleted by this one cancel out.
+		if idx := indexOfLine(cf.file.Right, deleted.LineNo); idx >= 0 {
+			cf.file.Right = append(cf.file.Right[:idx], cf.file.Right[idx+1:]...)
+			continue
+		}
+
+		lineNo := deleted.LineNo
+		for h := len(cf.history) - 1; h >= 0; h-- {
+			lineNo, _ = cf.history[h].OldLineNo(lineNo)
+		}
+
+		cf.file.Left = append(cf.file.Left, Line{LineNo: lineNo, Content: deleted.Content})
+	}
+
+	for n, added := range cf.file.Right {
+		cf.file.Right[n].LineNo, _ = pf.NewLineNo(added.LineNo)
+	}
+	for _, added := range pf.Right {
+		// Moved lines are detected again for the combined diff
+		cf.file.Right = append(cf.file.Right, Line{LineNo: added.LineNo, Content: added.Content})
+	}
+	// Keep the lines sorted so they can be mapped by the next patch.
+	sort.Slice(cf.file.Right, func(a, b int) bool { return cf.file.Right[a].LineNo < cf.file.Right[b].LineNo })
+
+	cf.history = append(cf.history, pf)
+	cf.file.Name = pf.Name
+	cf.file.Truncated = cf.file.Truncated || pf.Truncated
+
+	switch {
+	case pf.Operation == DiffOperationDelete:
+		if cf.file.Operation == DiffOperationNew {
+			cf.file.Right = make([]Line, 0)
+		} else {
+			cf.file.Operation = DiffOperationDelete
+		}
+	case cf.file.Operation == DiffOperationNew || cf.file.Operation == DiffOperationCopy:
+	case cf.file.OldName != cf.file.Name:
+		cf.file.Operation = DiffOperationRename
+	default:
+		cf.file.Operation = DiffOperationChange
+	}
+}
+
+func indexOfLine(lines []Line, lineNo uint) int {
+	for n, l := range lines {
+		if l.LineNo == lineNo {
+			return n
+		}
+	}
+
+	return -1
+}
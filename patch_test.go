@@ -0,0 +1,149 @@
+package manifest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var patchSeries = `From 0000000000000000000000000000000000000000 Mon Sep 17 00:00:00 2001
+From: Jane Doe <jane@example.com>
+Date: Tue, 2 Jan 2024 03:04:05 +0000
+Subject: [PATCH 0/2] Capitalize numbers
+
+Makes some numbers louder.
+
+Jane Doe (2):
+  Capitalize two
+  Add zero and capitalize four
+
+ a.txt | 5 +++--
+ 1 file changed, 3 insertions(+), 2 deletions(-)
+
+From 1111111111111111111111111111111111111111 Mon Sep 17 00:00:00 2001
+From: Jane Doe <jane@example.com>
+Date: Tue, 2 Jan 2024 03:04:05 +0000
+Subject: [PATCH 1/2] Capitalize two
+
+Signed-off-by: Jane Doe <jane@example.com>
+
+diff --git a/a.txt b/a.txt
+index f384549..6addb9b 100644
+--- a/a.txt
++++ b/a.txt
+@@ -1,4 +1,4 @@
+ one
+-two
++TWO
+ three
+ four
+
+From 2222222222222222222222222222222222222222 Mon Sep 17 00:00:00 2001
+From: Jane Doe <jane@example.com>
+Date: Tue, 2 Jan 2024 03:04:05 +0000
+Subject: [PATCH 2/2] Add zero and capitalize four
+
+diff --git a/a.txt b/a.txt
+index 6addb9b..a0f3dac 100644
+--- a/a.txt
++++ b/a.txt
+@@ -1,4 +1,5 @@
++zero
+ one
+ TWO
+ three
+-four
++FOUR
+`
+
+func TestParsePatchSeries(t *testing.T) {
+	series, err := ParsePatchSeries(strings.NewReader(patchSeries))
+	require.NoError(t, err)
+
+	require.NotNil(t, series.CoverLetter)
+	require.Equal(t, "Capitalize numbers", series.CoverLetter.Subject)
+	require.Equal(t, "Makes some numbers louder.", series.CoverLetter.Body)
+
+	require.Len(t, series.Patches, 2)
+
+	first := series.Patches[0].Commit
+	require.Equal(t, "1111111111111111111111111111111111111111", first.Sha)
+	require.Equal(t, "Capitalize two", first.Subject)
+	require.Equal(t, "Jane Doe", first.Author.Name)
+	require.Equal(t, "jane@example.com", first.Author.Email)
+	require.Equal(t, 2024, first.Author.Date.Year())
+	require.Equal(t, []Trailer{{Key: "Signed-off-by", Value: "Jane Doe <jane@example.com>"}}, first.Trailers)
+	require.Equal(t, []string{"a.txt"}, first.Files)
+
+	second := series.Patches[1].Diff.Files["a.txt"]
+	require.Equal(t, []Line{{LineNo: 4, Content: "four\n"}}, second.Left)
+	require.Equal(t, []Line{{LineNo: 1, Content: "zero\n"}, {LineNo: 5, Content: "FOUR\n"}}, second.Right)
+}
+
+func TestParsePatchSeries_CombinedDiff(t *testing.T) {
+	series, err := ParsePatchSeries(strings.NewReader(patchSeries))
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"a.txt"}, series.Diff.ChangedFiles)
+
+	file := series.Diff.Files["a.txt"]
+	require.Equal(t, DiffOperationChange, file.Operation)
+	require.Equal(t, []Line{{LineNo: 2, Content: "two\n"}, {LineNo: 4, Content: "four\n"}}, file.Left)
+	require.Equal(t, []Line{
+		{LineNo: 1, Content: "zero\n"},
+		{LineNo: 3, Content: "TWO\n"},
+		{LineNo: 5, Content: "FOUR\n"},
+	}, file.Right)
+}
+
+func TestParsePatchSeries_SkipsMalformedFiles(t *testing.T) {
+	patch := `From 1111111111111111111111111111111111111111 Mon Sep 17 00:00:00 2001
+From: Jane Doe <jane@example.com>
+Date: Tue, 2 Jan 2024 03:04:05 +0000
+Subject: [PATCH] Update files
+` + malformedDiff
+
+	series, err := ParsePatchSeries(strings.NewReader(patch))
+	require.NoError(t, err)
+
+	require.Len(t, series.Patches, 1)
+	require.Equal(t, "Update files", series.Patches[0].Commit.Subject)
+	require.Equal(t, []string{"README.md"}, series.Patches[0].Commit.Files)
+	require.Len(t, series.Patches[0].Diff.Warnings, 1)
+	require.Contains(t, series.Patches[0].Diff.Warnings[0], "skipped a/broken.txt b/broken.txt")
+
+	require.Equal(t, []string{"README.md"}, series.Diff.ChangedFiles)
+	require.Len(t, series.Diff.Warnings, 1)
+	require.Contains(t, series.Diff.Warnings[0], "patch 1: skipped a/broken.txt b/broken.txt")
+}
+
+func TestParsePatchSeries_InvalidPatch(t *testing.T) {
+	_, err := ParsePatchSeries(strings.NewReader("Subject: [PATCH] Broken\n\ndiff --git a/x b/x\n@@ -1 +1 @@\nnope\n"))
+	require.ErrorContains(t, err, "failed to parse patch 1")
+}
+
+func TestFile_LineNoMapping(t *testing.T) {
+	file := File{
+		Left:  []Line{{LineNo: 2}},
+		Right: []Line{{LineNo: 4}},
+	}
+
+	newLineNo, ok := file.NewLineNo(5)
+	require.True(t, ok)
+	require.Equal(t, uint(5), newLineNo)
+
+	newLineNo, ok = file.NewLineNo(3)
+	require.True(t, ok)
+	require.Equal(t, uint(2), newLineNo)
+
+	_, ok = file.NewLineNo(2)
+	require.False(t, ok, "expected deleted line to not be mapped")
+
+	oldLineNo, ok := file.OldLineNo(5)
+	require.True(t, ok)
+	require.Equal(t, uint(5), oldLineNo)
+
+	_, ok = file.OldLineNo(4)
+	require.False(t, ok, "expected added line to not be mapped")
+}
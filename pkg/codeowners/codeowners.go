@@ -0,0 +1,104 @@
+package codeowners
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/blakewilliams/manifest/pkg/pathmatch"
+)
+
+// Locations are the paths, relative to the repository root, that GitHub reads
+// CODEOWNERS from, in order of precedence.
+var Locations = []string{
+	".github/CODEOWNERS",
+	"CODEOWNERS",
+	"docs/CODEOWNERS",
+}
+
+// CodeOwners is a parsed CODEOWNERS file.
+type CodeOwners struct {
+	rules []rule
+}
+
+type rule struct {
+	pattern *pathmatch.Pattern
+	owners  []string
+}
+
+// Parse parses the given CODEOWNERS content.
+func Parse(r io.Reader) (*CodeOwners, error) {
+	co := &CodeOwners{}
+
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+
+		line := scanner.Text()
+		if idx := strings.Index(line, "#"); idx >= 0 && (idx == 0 || line[idx-1] != '\\') {
+			line = line[:idx]
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		pattern, err := pathmatch.Compile(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+
+		co.rules = append(co.rules, rule{pattern: pattern, owners: fields[1:]})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return co, nil
+}
+
+// Load parses the CODEOWNERS file in the given repository root, using the
+// first file found in Locations. It returns nil if no CODEOWNERS file exists.
+func Load(root string) (*CodeOwners, error) {
+	for _, location := range Locations {
+		path := filepath.Join(root, location)
+
+		f, err := os.Open(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not open %s: %w", path, err)
+		}
+		defer f.Close()
+
+		co, err := Parse(f)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse %s: %w", path, err)
+		}
+
+		return co, nil
+	}
+
+	return nil, nil
+}
+
+// For returns the owners of the given path. The last matching rule wins, and
+// a matching rule without owners leaves the path unowned.
+func (co *CodeOwners) For(path string) []string {
+	for i := len(co.rules) - 1; i >= 0; i-- {
+		if co.rules[i].pattern.MatchOrParent(path) {
+			return co.rules[i].owners
+		}
+	}
+
+	return nil
+}
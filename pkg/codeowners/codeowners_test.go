@@ -0,0 +1,37 @@
+package codeowners
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var codeowners = `
+# Default owners
+*       @acme/engineering
+
+*.rb    @acme/ruby @jane # inline comment
+/payments/ @acme/payments
+docs/   docs@example.com
+/payments/README.md
+`
+
+func TestFor(t *testing.T) {
+	co, err := Parse(strings.NewReader(codeowners))
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"@acme/engineering"}, co.For("main.go"))
+	require.Equal(t, []string{"@acme/ruby", "@jane"}, co.For("app/models/user.rb"))
+	require.Equal(t, []string{"@acme/payments"}, co.For("payments/charge.rb"))
+	require.Equal(t, []string{"docs@example.com"}, co.For("guides/docs/intro.md"))
+	require.Empty(t, co.For("payments/README.md"))
+}
+
+func TestFor_WildcardDoesNotMatchSubdirectories(t *testing.T) {
+	co, err := Parse(strings.NewReader("*  @acme/engineering\ndocs/*  docs@example.com\n"))
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"docs@example.com"}, co.For("docs/getting-started.md"))
+	require.Equal(t, []string{"@acme/engineering"}, co.For("docs/build-app/troubleshooting.md"))
+}
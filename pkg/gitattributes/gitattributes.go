@@ -0,0 +1,152 @@
+package gitattributes
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/blakewilliams/manifest/pkg/pathmatch"
+)
+
+// Attributes is a parsed set of .gitattributes rules.
+//
+// Attribute values follow the gitattributes format: set attributes (`attr`)
+// have the value "true", unset attributes (`-attr`) have the value "false",
+// and attributes with a value (`attr=value`) have that value. Unspecified
+// attributes (`!attr`) are removed.
+type Attributes struct {
+	rules  []rule
+	macros map[string][]string
+}
+
+type rule struct {
+	pattern *pathmatch.Pattern
+	attrs   []string
+}
+
+// builtinMacros are the macros git defines without them being present in a
+// .gitattributes file.
+var builtinMacros = map[string][]string{
+	"binary": {"-diff", "-merge", "-text"},
+}
+
+// Parse parses the given .gitattributes content.
+func Parse(r io.Reader) (*Attributes, error) {
+	a := &Attributes{macros: make(map[string][]string, len(builtinMacros))}
+	for name, attrs := range builtinMacros {
+		a.macros[name] = attrs
+	}
+
+	if err := a.parse(r); err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
+
+// Load parses the .gitattributes file in the given repository root and
+// .git/info/attributes, if they exist. Missing files are ignored.
+func Load(root string) (*Attributes, error) {
+	a, err := Parse(strings.NewReader(""))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, path := range []string{
+		filepath.Join(root, ".gitattributes"),
+		filepath.Join(root, ".git", "info", "attributes"),
+	} {
+		f, err := os.Open(path)
+		// .git is a file instead of a directory in worktrees and submodules
+		if errors.Is(err, fs.ErrNotExist) || errors.Is(err, syscall.ENOTDIR) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not open %s: %w", path, err)
+		}
+
+		err = a.parse(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("could not parse %s: %w", path, err)
+		}
+	}
+
+	return a, nil
+}
+
+func (a *Attributes) parse(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		pattern, attrs := fields[0], fields[1:]
+
+		if strings.HasPrefix(pattern, "[attr]") {
+			a.macros[strings.TrimPrefix(pattern, "[attr]")] = attrs
+			continue
+		}
+
+		compiled, err := pathmatch.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", lineNo, err)
+		}
+
+		a.rules = append(a.rules, rule{pattern: compiled, attrs: attrs})
+	}
+
+	return scanner.Err()
+}
+
+// For returns the attributes that apply to the given path. Later rules take
+// precedence over earlier ones.
+func (a *Attributes) For(path string) map[string]string {
+	values := make(map[string]string)
+
+	for _, r := range a.rules {
+		if !r.pattern.Match(path) {
+			continue
+		}
+
+		a.apply(values, r.attrs, 0)
+	}
+
+	return values
+}
+
+func (a *Attributes) apply(values map[string]string, attrs []string, depth int) {
+	// Guard against macros that reference each other
+	if depth > 10 {
+		return
+	}
+
+	for _, attr := range attrs {
+		switch {
+		case strings.HasPrefix(attr, "-"):
+			values[attr[1:]] = "false"
+		case strings.HasPrefix(attr, "!"):
+			delete(values, attr[1:])
+		case strings.Contains(attr, "="):
+			name, value, _ := strings.Cut(attr, "=")
+			values[name] = value
+		default:
+			values[attr] = "true"
+			if macro, ok := a.macros[attr]; ok {
+				a.apply(values, macro, depth+1)
+			}
+		}
+	}
+}
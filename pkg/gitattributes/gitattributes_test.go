@@ -0,0 +1,32 @@
+package gitattributes
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var attributes = `
+# Generated code
+*.pb.go linguist-generated
+vendor/** linguist-vendored
+api/*.pb.go -linguist-generated
+*.png binary
+[attr]docs linguist-documentation owner=docs
+docs/** docs
+*.sql -diff !owner
+`
+
+func TestFor(t *testing.T) {
+	a, err := Parse(strings.NewReader(attributes))
+	require.NoError(t, err)
+
+	require.Equal(t, map[string]string{"linguist-generated": "true"}, a.For("rpc/service.pb.go"))
+	require.Equal(t, map[string]string{"linguist-generated": "false"}, a.For("api/service.pb.go"))
+	require.Equal(t, map[string]string{"linguist-vendored": "true"}, a.For("vendor/github.com/foo/foo.go"))
+	require.Equal(t, map[string]string{"binary": "true", "diff": "false", "merge": "false", "text": "false"}, a.For("logo.png"))
+	require.Equal(t, map[string]string{"docs": "true", "linguist-documentation": "true", "owner": "docs"}, a.For("docs/index.md"))
+	require.Equal(t, map[string]string{"docs": "true", "linguist-documentation": "true", "diff": "false"}, a.For("docs/schema.sql"))
+	require.Empty(t, a.For("main.go"))
+}
@@ -0,0 +1,90 @@
+package linediff
+
+import (
+	"strings"
+)
+
+// Op is the operation applied to a line in a diff.
+type Op byte
+
+const (
+	Equal  Op = ' '
+	Delete Op = '-'
+	Insert Op = '+'
+)
+
+// Line is a single line of a diff.
+type Line struct {
+	Op   Op
+	Text string
+}
+
+// Diff returns the line by line diff between a and b using the longest common
+// subsequence of their lines. It's meant for small inputs like test output.
+func Diff(a string, b string) []Line {
+	left := splitLines(a)
+	right := splitLines(b)
+
+	// lcs[i][j] is the length of the longest common subsequence of left[i:]
+	// and right[j:].
+	lcs := make([][]int, len(left)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(right)+1)
+	}
+	for i := len(left) - 1; i >= 0; i-- {
+		for j := len(right) - 1; j >= 0; j-- {
+			if left[i] == right[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	lines := make([]Line, 0, max(len(left), len(right)))
+	i, j := 0, 0
+	for i < len(left) && j < len(right) {
+		switch {
+		case left[i] == right[j]:
+			lines = append(lines, Line{Op: Equal, Text: left[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, Line{Op: Delete, Text: left[i]})
+			i++
+		default:
+			lines = append(lines, Line{Op: Insert, Text: right[j]})
+			j++
+		}
+	}
+	for ; i < len(left); i++ {
+		lines = append(lines, Line{Op: Delete, Text: left[i]})
+	}
+	for ; j < len(right); j++ {
+		lines = append(lines, Line{Op: Insert, Text: right[j]})
+	}
+
+	return lines
+}
+
+// String returns the diff between a and b with each line prefixed by its
+// operation, like a unified diff without hunk headers.
+func String(a string, b string) string {
+	var out strings.Builder
+	for _, line := range Diff(a, b) {
+		out.WriteByte(byte(line.Op))
+		out.WriteString(line.Text)
+		out.WriteByte('\n')
+	}
+
+	return out.String()
+}
+
+func splitLines(s string) []string {
+	s = strings.TrimSuffix(s, "\n")
+	if s == "" {
+		return nil
+	}
+
+	return strings.Split(s, "\n")
+}
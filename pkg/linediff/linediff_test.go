@@ -0,0 +1,28 @@
+package linediff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff(t *testing.T) {
+	a := "{\n  \"comments\": [\n    \"a\",\n    \"b\"\n  ]\n}\n"
+	b := "{\n  \"comments\": [\n    \"a\",\n    \"c\"\n  ]\n}\n"
+
+	require.Equal(t, []Line{
+		{Op: Equal, Text: "{"},
+		{Op: Equal, Text: `  "comments": [`},
+		{Op: Equal, Text: `    "a",`},
+		{Op: Delete, Text: `    "b"`},
+		{Op: Insert, Text: `    "c"`},
+		{Op: Equal, Text: "  ]"},
+		{Op: Equal, Text: "}"},
+	}, Diff(a, b))
+}
+
+func TestString(t *testing.T) {
+	require.Equal(t, " a\n-b\n+c\n+d\n", String("a\nb\n", "a\nc\nd"))
+	require.Equal(t, "+a\n", String("", "a\n"))
+	require.Equal(t, "", String("", ""))
+}
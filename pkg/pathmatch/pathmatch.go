@@ -0,0 +1,164 @@
+package pathmatch
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Pattern is a compiled gitignore style pattern, as used by .gitattributes
+// and CODEOWNERS files.
+//
+// Patterns without a slash match at any depth, while patterns containing a
+// slash are anchored to the root. `*` matches anything but a slash, `**`
+// matches across directories, and a trailing slash only matches directories.
+type Pattern struct {
+	raw     string
+	re      *regexp.Regexp
+	dirOnly bool
+	// namesDir is set when the pattern can name a directory, which is when it
+	// has a trailing slash or its last segment has no wildcards
+	namesDir bool
+}
+
+// Compile compiles the given pattern.
+func Compile(pattern string) (*Pattern, error) {
+	p := &Pattern{raw: pattern}
+
+	pattern = strings.TrimPrefix(pattern, `\`)
+	if strings.HasSuffix(pattern, "/") {
+		p.dirOnly = true
+		pattern = strings.TrimRight(pattern, "/")
+	}
+
+	anchored := strings.Contains(pattern, "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+
+	if pattern == "" {
+		return nil, fmt.Errorf("invalid empty pattern %q", p.raw)
+	}
+	p.namesDir = p.dirOnly || !strings.ContainsAny(pattern[strings.LastIndexByte(pattern, '/')+1:], "*?[")
+
+	var expr strings.Builder
+	expr.WriteString("^")
+	if !anchored {
+		expr.WriteString("(?:.*/)?")
+	}
+
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+
+		switch c {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				i++
+				if i+1 < len(pattern) && pattern[i+1] == '/' {
+					// `**/` matches zero or more directories
+					i++
+					expr.WriteString("(?:.*/)?")
+				} else {
+					expr.WriteString(".*")
+				}
+			} else {
+				expr.WriteString("[^/]*")
+			}
+		case '?':
+			expr.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(pattern[i:], ']')
+			if end < 0 {
+				expr.WriteString(regexp.QuoteMeta(string(c)))
+				continue
+			}
+			class := pattern[i+1 : i+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			expr.WriteString("[" + class + "]")
+			i += end
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+			}
+			expr.WriteString(regexp.QuoteMeta(string(pattern[i])))
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	expr.WriteString("$")
+
+	re, err := regexp.Compile(expr.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", p.raw, err)
+	}
+	p.re = re
+
+	return p, nil
+}
+
+// MustCompile is like Compile but panics if the pattern is invalid.
+func MustCompile(pattern string) *Pattern {
+	p, err := Compile(pattern)
+	if err != nil {
+		panic(err)
+	}
+
+	return p
+}
+
+// String returns the pattern as it was provided to Compile.
+func (p *Pattern) String() string {
+	return p.raw
+}
+
+// Match returns true if the pattern matches the given file path.
+func (p *Pattern) Match(path string) bool {
+	if p.dirOnly {
+		return false
+	}
+
+	return p.re.MatchString(strings.TrimPrefix(path, "/"))
+}
+
+// MatchOrParent returns true if the pattern matches the given file path or
+// any of its parent directories. Like in CODEOWNERS files, only patterns
+// naming a directory match its contents: `docs/` and `docs` match
+// `docs/guides/intro.md`, but `docs/*` only matches the files directly in
+// docs.
+func (p *Pattern) MatchOrParent(path string) bool {
+	path = strings.TrimPrefix(path, "/")
+	if p.Match(path) {
+		return true
+	}
+	if !p.namesDir {
+		return false
+	}
+
+	for dir := parentDir(path); dir != ""; dir = parentDir(dir) {
+		if p.re.MatchString(dir) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Match compiles the pattern and returns true if it matches the given file
+// path. Invalid patterns never match.
+func Match(pattern string, path string) bool {
+	p, err := Compile(pattern)
+	if err != nil {
+		return false
+	}
+
+	return p.Match(path)
+}
+
+func parentDir(path string) string {
+	idx := strings.LastIndexByte(path, '/')
+	if idx < 0 {
+		return ""
+	}
+
+	return path[:idx]
+}
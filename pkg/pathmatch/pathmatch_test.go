@@ -0,0 +1,45 @@
+package pathmatch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatch(t *testing.T) {
+	cases := []struct {
+		pattern string
+		path    string
+		match   bool
+	}{
+		{"*.go", "main.go", true},
+		{"*.go", "cmd/manifest/main.go", true},
+		{"*.go", "main.rb", false},
+		{"/*.go", "cmd/main.go", false},
+		{"app/*.rb", "app/models/user.rb", false},
+		{"app/**/*.rb", "app/models/user.rb", true},
+		{"app/**/*.rb", "app/user.rb", true},
+		{"**/*_job.rb", "app/jobs/greeter_job.rb", true},
+		{"**/*_job.rb", "greeter_job.rb", true},
+		{"vendor/**", "vendor/github.com/foo/bar.go", true},
+		{"vendor/**", "src/vendor/foo.go", false},
+		{"file?.txt", "file1.txt", true},
+		{"file[0-9].txt", "filea.txt", false},
+		{"docs/", "docs", false},
+	}
+
+	for _, c := range cases {
+		require.Equal(t, c.match, Match(c.pattern, c.path), "expected %q matching %q to be %t", c.pattern, c.path, c.match)
+	}
+}
+
+func TestMatchOrParent(t *testing.T) {
+	require.True(t, MustCompile("docs/").MatchOrParent("docs/README.md"))
+	require.True(t, MustCompile("apps/").MatchOrParent("src/apps/main.go"))
+	require.True(t, MustCompile("/payments").MatchOrParent("payments/charge.go"))
+	require.False(t, MustCompile("/payments").MatchOrParent("app/payments/charge.go"))
+	require.True(t, MustCompile("*.go").MatchOrParent("main.go"))
+	require.True(t, MustCompile("docs/*").MatchOrParent("docs/README.md"))
+	require.False(t, MustCompile("docs/*").MatchOrParent("docs/build-app/troubleshooting.md"))
+	require.False(t, MustCompile("*.md").MatchOrParent("docs.md/guide.txt"))
+}
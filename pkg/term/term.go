@@ -0,0 +1,77 @@
+// Package term puts the controlling terminal in raw mode to build interactive
+// interfaces, even when stdin and stdout are redirected.
+package term
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// ErrUnsupported is returned by Open on platforms without terminal support.
+var ErrUnsupported = errors.New("interactive terminals are not supported on this platform")
+
+// Terminal is the controlling terminal of the process.
+type Terminal struct {
+	*os.File
+
+	// original is the state of the terminal before it was put in raw mode
+	original *state
+}
+
+// Open opens the controlling terminal, /dev/tty, for reading and writing.
+func Open() (*Terminal, error) {
+	if !supported {
+		return nil, ErrUnsupported
+	}
+
+	f, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return nil, fmt.Errorf("could not open the terminal: %w", err)
+	}
+
+	return &Terminal{File: f}, nil
+}
+
+// Raw puts the terminal in raw mode: input is available byte by byte, without
+// being echoed, and keys like Ctrl-C are read instead of sending signals.
+// Output processing is left enabled so "\n" still starts a new line.
+func (t *Terminal) Raw() error {
+	original, err := getState(int(t.Fd()))
+	if err != nil {
+		return fmt.Errorf("could not get the terminal state: %w", err)
+	}
+
+	if err := makeRaw(int(t.Fd()), *original); err != nil {
+		return fmt.Errorf("could not put the terminal in raw mode: %w", err)
+	}
+	if t.original == nil {
+		t.original = original
+	}
+
+	return nil
+}
+
+// Restore restores the state of the terminal from before Raw was called.
+func (t *Terminal) Restore() error {
+	if t.original == nil {
+		return nil
+	}
+
+	if err := setState(int(t.Fd()), t.original); err != nil {
+		return fmt.Errorf("could not restore the terminal state: %w", err)
+	}
+	t.original = nil
+
+	return nil
+}
+
+// Size returns the width and height of the terminal.
+func (t *Terminal) Size() (int, int, error) {
+	return size(int(t.Fd()))
+}
+
+// Close restores and closes the terminal.
+func (t *Terminal) Close() error {
+	return errors.Join(t.Restore(), t.File.Close())
+}
@@ -0,0 +1,10 @@
+//go:build linux
+
+package term
+
+import "syscall"
+
+const (
+	ioctlReadTermios  = syscall.TCGETS
+	ioctlWriteTermios = syscall.TCSETS
+)
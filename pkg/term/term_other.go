@@ -0,0 +1,23 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd)
+
+package term
+
+const supported = false
+
+type state struct{}
+
+func getState(fd int) (*state, error) {
+	return nil, ErrUnsupported
+}
+
+func setState(fd int, s *state) error {
+	return ErrUnsupported
+}
+
+func makeRaw(fd int, s state) error {
+	return ErrUnsupported
+}
+
+func size(fd int) (int, int, error) {
+	return 0, 0, ErrUnsupported
+}
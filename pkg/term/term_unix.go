@@ -0,0 +1,65 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd
+
+package term
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+const supported = true
+
+type state struct {
+	termios syscall.Termios
+}
+
+type winsize struct {
+	Row    uint16
+	Col    uint16
+	Xpixel uint16
+	Ypixel uint16
+}
+
+func ioctl(fd int, request uintptr, arg unsafe.Pointer) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), request, uintptr(arg))
+	if errno != 0 {
+		return errno
+	}
+
+	return nil
+}
+
+func getState(fd int) (*state, error) {
+	s := &state{}
+	if err := ioctl(fd, ioctlReadTermios, unsafe.Pointer(&s.termios)); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func setState(fd int, s *state) error {
+	termios := s.termios
+	return ioctl(fd, ioctlWriteTermios, unsafe.Pointer(&termios))
+}
+
+func makeRaw(fd int, s state) error {
+	termios := s.termios
+	termios.Iflag &^= syscall.IGNBRK | syscall.BRKINT | syscall.PARMRK | syscall.ISTRIP | syscall.INLCR | syscall.IGNCR | syscall.ICRNL | syscall.IXON
+	termios.Lflag &^= syscall.ECHO | syscall.ECHONL | syscall.ICANON | syscall.ISIG | syscall.IEXTEN
+	termios.Cflag &^= syscall.CSIZE | syscall.PARENB
+	termios.Cflag |= syscall.CS8
+	termios.Cc[syscall.VMIN] = 1
+	termios.Cc[syscall.VTIME] = 0
+
+	return ioctl(fd, ioctlWriteTermios, unsafe.Pointer(&termios))
+}
+
+func size(fd int) (int, int, error) {
+	ws := &winsize{}
+	if err := ioctl(fd, syscall.TIOCGWINSZ, unsafe.Pointer(ws)); err != nil {
+		return 0, 0, err
+	}
+
+	return int(ws.Col), int(ws.Row), nil
+}
@@ -0,0 +1,94 @@
+package watch
+
+import (
+	"context"
+	"io/fs"
+	"path/filepath"
+	"time"
+)
+
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// poll watches root by scanning it every PollInterval and comparing the
+// modification time and size of every file.
+func poll(ctx context.Context, root string, skip SkipFunc, events chan<- string) error {
+	previous, err := snapshot(root, skip)
+	if err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		current, err := snapshot(root, skip)
+		if err != nil {
+			return err
+		}
+
+		for path, state := range current {
+			if old, ok := previous[path]; !ok || old != state {
+				if !send(ctx, events, path) {
+					return nil
+				}
+			}
+		}
+		for path := range previous {
+			if _, ok := current[path]; !ok {
+				if !send(ctx, events, path) {
+					return nil
+				}
+			}
+		}
+
+		previous = current
+	}
+}
+
+// snapshot returns the state of every file under root, keyed by its path
+// relative to root.
+func snapshot(root string, skip SkipFunc) (map[string]fileState, error) {
+	files := make(map[string]fileState)
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Files can be removed while walking
+			if path != root {
+				return nil
+			}
+			return err
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if d.IsDir() {
+			if rel != "." && skip != nil && skip(rel) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		files[rel] = fileState{modTime: info.ModTime(), size: info.Size()}
+
+		return nil
+	})
+
+	return files, err
+}
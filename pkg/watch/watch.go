@@ -0,0 +1,68 @@
+package watch
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// Debounce is how long Watch waits for changes to settle before reporting
+// them, so saving several files or switching branches is reported once.
+var Debounce = 100 * time.Millisecond
+
+// PollInterval is how often the tree is scanned when changes can't be watched
+// using the operating system.
+var PollInterval = 500 * time.Millisecond
+
+// SkipFunc returns true if the directory, relative to the watched root,
+// shouldn't be watched.
+type SkipFunc func(dir string) bool
+
+// Watch calls changed with the paths of the files changed under root,
+// relative to root, until ctx is done. The paths are sorted and may include
+// deleted files.
+func Watch(ctx context.Context, root string, skip SkipFunc, changed func(paths []string)) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	events := make(chan string)
+	errs := make(chan error, 1)
+	go func() {
+		errs <- watch(ctx, root, skip, events)
+	}()
+
+	pending := make(map[string]bool)
+	var timer <-chan time.Time
+
+	for {
+		select {
+		case <-ctx.Done():
+			return <-errs
+		case err := <-errs:
+			return err
+		case path := <-events:
+			pending[path] = true
+			timer = time.After(Debounce)
+		case <-timer:
+			paths := make([]string, 0, len(pending))
+			for path := range pending {
+				paths = append(paths, path)
+			}
+			sort.Strings(paths)
+			clear(pending)
+			timer = nil
+
+			changed(paths)
+		}
+	}
+}
+
+// send sends the path to events, returning false if ctx is done.
+func send(ctx context.Context, events chan<- string, path string) bool {
+	select {
+	case events <- path:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,157 @@
+//go:build linux
+
+package watch
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+const inotifyMask = syscall.IN_CREATE | syscall.IN_DELETE | syscall.IN_MODIFY |
+	syscall.IN_CLOSE_WRITE | syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO
+
+// watch watches root using inotify, falling back to polling when inotify is
+// unavailable or the watch limit is reached.
+func watch(ctx context.Context, root string, skip SkipFunc, events chan<- string) error {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return poll(ctx, root, skip, events)
+	}
+
+	// The file is non-blocking so reads go through the runtime poller and are
+	// interrupted by Close.
+	file := os.NewFile(uintptr(fd), "inotify")
+	defer file.Close()
+
+	w := &inotifyWatcher{fd: fd, root: root, skip: skip, dirs: make(map[int32]string)}
+	if _, err := w.addTree(root); err != nil {
+		if errors.Is(err, syscall.ENOSPC) {
+			file.Close()
+			return poll(ctx, root, skip, events)
+		}
+		return err
+	}
+
+	go func() {
+		<-ctx.Done()
+		file.Close()
+	}()
+
+	buf := make([]byte, 64*1024)
+	for {
+		n, err := file.Read(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("could not read inotify events: %w", err)
+		}
+
+		for _, path := range w.handle(buf[:n]) {
+			if !send(ctx, events, path) {
+				return nil
+			}
+		}
+	}
+}
+
+type inotifyWatcher struct {
+	fd   int
+	root string
+	skip SkipFunc
+	// dirs maps watch descriptors to the directory they watch.
+	dirs map[int32]string
+}
+
+// addTree watches dir and every directory below it that isn't skipped,
+// returning the files found in them.
+func (w *inotifyWatcher) addTree(dir string) ([]string, error) {
+	files := make([]string, 0)
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path != dir {
+				return nil
+			}
+			return err
+		}
+
+		rel := w.rel(path)
+		if !d.IsDir() {
+			files = append(files, rel)
+			return nil
+		}
+		if rel != "." && w.skip != nil && w.skip(rel) {
+			return filepath.SkipDir
+		}
+
+		wd, err := syscall.InotifyAddWatch(w.fd, path, inotifyMask)
+		if err != nil {
+			return fmt.Errorf("could not watch %s: %w", path, err)
+		}
+		w.dirs[int32(wd)] = path
+
+		return nil
+	})
+
+	return files, err
+}
+
+// handle parses the inotify events in buf, returning the changed paths.
+func (w *inotifyWatcher) handle(buf []byte) []string {
+	paths := make([]string, 0)
+
+	for offset := 0; offset+syscall.SizeofInotifyEvent <= len(buf); {
+		wd := int32(binary.NativeEndian.Uint32(buf[offset:]))
+		mask := binary.NativeEndian.Uint32(buf[offset+4:])
+		nameLen := int(binary.NativeEndian.Uint32(buf[offset+12:]))
+		start := offset + syscall.SizeofInotifyEvent
+		name := string(bytes.TrimRight(buf[start:start+nameLen], "\x00"))
+		offset = start + nameLen
+
+		if mask&syscall.IN_IGNORED != 0 {
+			delete(w.dirs, wd)
+			continue
+		}
+
+		dir, ok := w.dirs[wd]
+		if !ok || name == "" {
+			continue
+		}
+		path := filepath.Join(dir, name)
+
+		if mask&syscall.IN_ISDIR == 0 {
+			paths = append(paths, w.rel(path))
+			continue
+		}
+
+		// Directories created or moved into the tree are watched, and the
+		// files already in them reported, since they were added before the
+		// watch existed.
+		if mask&(syscall.IN_CREATE|syscall.IN_MOVED_TO) != 0 {
+			if w.skip != nil && w.skip(w.rel(path)) {
+				continue
+			}
+			files, _ := w.addTree(path)
+			paths = append(paths, files...)
+		}
+	}
+
+	return paths
+}
+
+func (w *inotifyWatcher) rel(path string) string {
+	rel, err := filepath.Rel(w.root, path)
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+
+	return filepath.ToSlash(rel)
+}
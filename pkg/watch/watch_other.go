@@ -0,0 +1,9 @@
+//go:build !linux
+
+package watch
+
+import "context"
+
+func watch(ctx context.Context, root string, skip SkipFunc, events chan<- string) error {
+	return poll(ctx, root, skip, events)
+}
@@ -0,0 +1,94 @@
+package watch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func watchChanges(t *testing.T, root string, watcher func(context.Context, string, SkipFunc, chan<- string) error) <-chan string {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	events := make(chan string, 16)
+	skip := func(dir string) bool { return dir == "ignored" }
+	go func() { _ = watcher(ctx, root, skip, events) }()
+
+	// Give the watcher time to set up
+	time.Sleep(50 * time.Millisecond)
+
+	return events
+}
+
+func waitFor(t *testing.T, events <-chan string, path string) {
+	t.Helper()
+
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case event := <-events:
+			require.NotEqual(t, "ignored/out.log", event, "skipped directories shouldn't be watched")
+			if event == path {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("timed out waiting for a change to %s", path)
+		}
+	}
+}
+
+func testWatcher(t *testing.T, watcher func(context.Context, string, SkipFunc, chan<- string) error) {
+	root := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(root, "ignored"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "README.md"), []byte("hi"), 0o644))
+
+	events := watchChanges(t, root, watcher)
+
+	require.NoError(t, os.WriteFile(filepath.Join(root, "ignored", "out.log"), []byte("log"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "README.md"), []byte("hello"), 0o644))
+	waitFor(t, events, "README.md")
+
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "app", "models"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "app", "models", "user.rb"), []byte("class User"), 0o644))
+	waitFor(t, events, "app/models/user.rb")
+}
+
+func TestWatch(t *testing.T) {
+	testWatcher(t, watch)
+}
+
+func TestPoll(t *testing.T) {
+	interval := PollInterval
+	t.Cleanup(func() { PollInterval = interval })
+	PollInterval = 20 * time.Millisecond
+	testWatcher(t, poll)
+}
+
+func TestWatch_Debounces(t *testing.T) {
+	root := t.TempDir()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	changes := make(chan []string, 4)
+	go func() {
+		_ = Watch(ctx, root, nil, func(paths []string) { changes <- paths })
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	require.NoError(t, os.WriteFile(filepath.Join(root, "b.txt"), []byte("b"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "a.txt"), []byte("a"), 0o644))
+
+	select {
+	case paths := <-changes:
+		require.Equal(t, []string{"a.txt", "b.txt"}, paths)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for changes")
+	}
+}
@@ -0,0 +1,188 @@
+package manifest
+
+import (
+	"iter"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/blakewilliams/manifest/pkg/pathmatch"
+)
+
+// testFilePatterns match the file names and directories conventionally used
+// for tests.
+var testFilePatterns = []*pathmatch.Pattern{
+	pathmatch.MustCompile("*_test.go"),
+	pathmatch.MustCompile("*_test.rb"),
+	pathmatch.MustCompile("*_spec.rb"),
+	pathmatch.MustCompile("test_*.py"),
+	pathmatch.MustCompile("*_test.py"),
+	pathmatch.MustCompile("*.test.[jt]s"),
+	pathmatch.MustCompile("*.test.[jt]sx"),
+	pathmatch.MustCompile("*.spec.[jt]s"),
+	pathmatch.MustCompile("*.spec.[jt]sx"),
+	pathmatch.MustCompile("test/"),
+	pathmatch.MustCompile("tests/"),
+	pathmatch.MustCompile("spec/"),
+	pathmatch.MustCompile("__tests__/"),
+}
+
+// path returns the new name of the file, or the old name if it was deleted.
+func (f File) path() string {
+	if f.Name == "" {
+		return f.OldName
+	}
+
+	return f.Name
+}
+
+// IsTestFile returns true if the file looks like a test based on the naming
+// conventions of common languages and test frameworks.
+func (f File) IsTestFile() bool {
+	for _, pattern := range testFilePatterns {
+		if pattern.MatchOrParent(f.path()) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// sortedFiles returns the files of the diff sorted by path so iteration is
+// deterministic.
+func (d Diff) sortedFiles() []File {
+	files := make([]File, 0, len(d.Files))
+	for _, file := range d.Files {
+		files = append(files, file)
+	}
+	sort.Slice(files, func(a, b int) bool { return files[a].path() < files[b].path() })
+
+	return files
+}
+
+// FilesMatching returns the files whose new name, or old name for deleted
+// files, matches any of the given gitignore style glob patterns. Patterns
+// without a slash match at any depth, e.g. "*_job.rb" or "app/**/*.rb". It
+// returns an error if any of the patterns is invalid.
+func (d Diff) FilesMatching(globs ...string) ([]File, error) {
+	patterns := make([]*pathmatch.Pattern, 0, len(globs))
+	for _, glob := range globs {
+		pattern, err := pathmatch.Compile(glob)
+		if err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, pattern)
+	}
+
+	files := make([]File, 0)
+	for _, file := range d.sortedFiles() {
+		for _, pattern := range patterns {
+			if pattern.Match(file.path()) {
+				files = append(files, file)
+				break
+			}
+		}
+	}
+
+	return files, nil
+}
+
+// FileByNewName returns the file with the given new name. Files is keyed by
+// the old name, so this should be used when looking up files by the name
+// they have after the diff is applied, like renamed files.
+func (d Diff) FileByNewName(name string) (File, bool) {
+	for _, file := range d.Files {
+		if file.Name == name {
+			return file, true
+		}
+	}
+
+	return File{}, false
+}
+
+// Touches returns true if the diff touches the given file, or any file in the
+// given directory. Both the old and new names of files are considered.
+func (d Diff) Touches(p string) bool {
+	p = strings.TrimSuffix(path.Clean(p), "/")
+
+	for _, file := range d.Files {
+		for _, name := range []string{file.Name, file.OldName} {
+			if name != "" && (name == p || strings.HasPrefix(name, p+"/")) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// AddedLines returns an iterator over every added line in the diff along with
+// the file it was added to.
+func (d Diff) AddedLines() iter.Seq2[File, Line] {
+	return func(yield func(File, Line) bool) {
+		for _, file := range d.sortedFiles() {
+			for _, line := range file.Right {
+				if !yield(file, line) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// RemovedLines returns an iterator over every removed line in the diff along
+// with the file it was removed from.
+func (d Diff) RemovedLines() iter.Seq2[File, Line] {
+	return func(yield func(File, Line) bool) {
+		for _, file := range d.sortedFiles() {
+			for _, line := range file.Left {
+				if !yield(file, line) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// AddedLinesMatching returns an iterator over every added line matching the
+// given regular expression along with the file it was added to.
+func (d Diff) AddedLinesMatching(re *regexp.Regexp) iter.Seq2[File, Line] {
+	return func(yield func(File, Line) bool) {
+		for file, line := range d.AddedLines() {
+			if re.MatchString(line.Content) && !yield(file, line) {
+				return
+			}
+		}
+	}
+}
+
+// FilesMatching is shorthand for Diff.FilesMatching.
+func (i *Import) FilesMatching(globs ...string) ([]File, error) {
+	return i.Diff.FilesMatching(globs...)
+}
+
+// FileByNewName is shorthand for Diff.FileByNewName.
+func (i *Import) FileByNewName(name string) (File, bool) {
+	return i.Diff.FileByNewName(name)
+}
+
+// Touches is shorthand for Diff.Touches.
+func (i *Import) Touches(path string) bool {
+	return i.Diff.Touches(path)
+}
+
+// AddedLines is shorthand for Diff.AddedLines.
+func (i *Import) AddedLines() iter.Seq2[File, Line] {
+	return i.Diff.AddedLines()
+}
+
+// RemovedLines is shorthand for Diff.RemovedLines.
+func (i *Import) RemovedLines() iter.Seq2[File, Line] {
+	return i.Diff.RemovedLines()
+}
+
+// AddedLinesMatching is shorthand for Diff.AddedLinesMatching.
+func (i *Import) AddedLinesMatching(re *regexp.Regexp) iter.Seq2[File, Line] {
+	return i.Diff.AddedLinesMatching(re)
+}
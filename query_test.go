@@ -0,0 +1,110 @@
+package manifest
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var queryDiff = `
+diff --git a/app/jobs/greeter_job.rb b/app/jobs/welcome_job.rb
+similarity index 90%
+rename from app/jobs/greeter_job.rb
+rename to app/jobs/welcome_job.rb
+index abc1234..def5678 100644
+--- a/app/jobs/greeter_job.rb
++++ b/app/jobs/welcome_job.rb
+@@ -1,3 +1,3 @@
+ class GreeterJob < ApplicationJob
+-  def perform
++  def perform(name)
+   end
+diff --git a/spec/jobs/greeter_job_spec.rb b/spec/jobs/greeter_job_spec.rb
+deleted file mode 100644
+index abc1234..0000000
+--- a/spec/jobs/greeter_job_spec.rb
++++ /dev/null
+@@ -1,1 +0,0 @@
+-describe GreeterJob
+diff --git a/main_test.go b/main_test.go
+new file mode 100644
+index 0000000..e69de29
+--- /dev/null
++++ b/main_test.go
+@@ -0,0 +1,2 @@
++package main
++// TODO: write tests`
+
+func TestDiff_FileByNewName(t *testing.T) {
+	diff, err := NewDiff(strings.NewReader(queryDiff))
+	require.NoError(t, err)
+
+	file, ok := diff.FileByNewName("app/jobs/welcome_job.rb")
+	require.True(t, ok)
+	require.Equal(t, "app/jobs/greeter_job.rb", file.OldName)
+
+	_, ok = diff.FileByNewName("app/jobs/greeter_job.rb")
+	require.False(t, ok)
+}
+
+func TestDiff_FilesMatching(t *testing.T) {
+	diff, err := NewDiff(strings.NewReader(queryDiff))
+	require.NoError(t, err)
+
+	files, err := diff.FilesMatching("*_job.rb", "*.go")
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	require.Equal(t, "app/jobs/welcome_job.rb", files[0].Name)
+	require.Equal(t, "main_test.go", files[1].Name)
+
+	files, err = diff.FilesMatching("spec/**")
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	require.Equal(t, "spec/jobs/greeter_job_spec.rb", files[0].OldName)
+
+	_, err = diff.FilesMatching("*.go", "[z-a].rb")
+	require.ErrorContains(t, err, `invalid pattern "[z-a].rb"`)
+}
+
+func TestDiff_Lines(t *testing.T) {
+	diff, err := NewDiff(strings.NewReader(queryDiff))
+	require.NoError(t, err)
+
+	added := make([]string, 0)
+	for file, line := range diff.AddedLines() {
+		added = append(added, file.Name+":"+line.Content)
+	}
+	require.Equal(t, []string{"app/jobs/welcome_job.rb:  def perform(name)\n", "main_test.go:package main\n", "main_test.go:// TODO: write tests"}, added)
+
+	removed := 0
+	for range diff.RemovedLines() {
+		removed++
+	}
+	require.Equal(t, 2, removed)
+
+	for file, line := range diff.AddedLinesMatching(regexp.MustCompile(`TODO`)) {
+		require.Equal(t, "main_test.go", file.Name)
+		require.Equal(t, uint(2), line.LineNo)
+	}
+}
+
+func TestDiff_Touches(t *testing.T) {
+	diff, err := NewDiff(strings.NewReader(queryDiff))
+	require.NoError(t, err)
+
+	require.True(t, diff.Touches("app/jobs"))
+	require.True(t, diff.Touches("app/jobs/greeter_job.rb"))
+	require.True(t, diff.Touches("spec/"))
+	require.False(t, diff.Touches("app/models"))
+	require.False(t, diff.Touches("app/job"))
+}
+
+func TestFile_IsTestFile(t *testing.T) {
+	require.True(t, File{Name: "main_test.go"}.IsTestFile())
+	require.True(t, File{OldName: "spec/jobs/greeter_job_spec.rb"}.IsTestFile())
+	require.True(t, File{Name: "src/__tests__/app.js"}.IsTestFile())
+	require.True(t, File{Name: "src/app.test.tsx"}.IsTestFile())
+	require.False(t, File{Name: "app/jobs/welcome_job.rb"}.IsTestFile())
+}
@@ -34,46 +34,93 @@ type Comment struct {
 	Text string `json:"text"`
 	// Severity of the comment. Defaults to Info.
 	Severity Severity `json:"severity"`
+	// MentionOwners asks formatters that support it to mention the CODEOWNERS
+	// owners of the commented file, or of the whole diff for top-level
+	// comments.
+	MentionOwners bool `json:"mentionOwners,omitempty"`
+	// Commit is the sha of the commit the comment applies to. It's set by
+	// manifest when running in per-commit mode.
+	Commit string `json:"commit,omitempty"`
+	// Rule is the optional ID of the rule that produced the comment. The rules
+	// of a checker are listed by `manifest explain`.
+	Rule string `json:"rule,omitempty"`
+	// Suggestion is the optional replacement for the commented line on the
+	// RIGHT side. It can span multiple lines, or be empty to remove the line.
+	// Suggestions are applied using `manifest check --interactive`.
+	Suggestion *string `json:"suggestion,omitempty"`
+}
+
+// Rule describes a rule a checker can report comments for.
+type Rule struct {
+	// ID is the value of Comment.Rule for comments reported by the rule.
+	ID          string `json:"id" yaml:"id"`
+	Description string `json:"description" yaml:"description"`
 }
 
 // Warn adds a general warning that will be shown to the user based on the
 // provided formatter.
 func (r *Result) Warn(message string) {
+	r.WarnRule("", message)
+}
+
+// WarnRule adds a general warning reported by the rule with the given ID.
+func (r *Result) WarnRule(rule string, message string) {
 	r.Comments = append(r.Comments, Comment{
 		Text:     message,
 		Severity: SeverityWarn,
+		Rule:     rule,
 	})
 }
 
 // WarnLine adds a warning to a specific line in a file that will be shown to the
 // user based on the provided formatter.
 func (r *Result) WarnLine(file string, side string, line uint, message string) {
+	r.WarnLineRule("", file, side, line, message)
+}
+
+// WarnLineRule adds a warning reported by the rule with the given ID to a
+// specific line in a file.
+func (r *Result) WarnLineRule(rule string, file string, side string, line uint, message string) {
 	r.Comments = append(r.Comments, Comment{
 		File:     file,
 		Line:     line,
 		Text:     message,
 		Side:     side,
 		Severity: SeverityWarn,
+		Rule:     rule,
 	})
 }
 
 // Error adds a general warning that will be shown to the user based on the
 // provided formatter.
 func (r *Result) Error(message string) {
+	r.ErrorRule("", message)
+}
+
+// ErrorRule adds a general error reported by the rule with the given ID.
+func (r *Result) ErrorRule(rule string, message string) {
 	r.Comments = append(r.Comments, Comment{
 		Text:     message,
 		Severity: SeverityError,
+		Rule:     rule,
 	})
 }
 
 // ErrorLine adds a warning to a specific line in a file that will be shown to the
 // user based on the provided formatter.
 func (r *Result) ErrorLine(file string, side string, line uint, message string) {
+	r.ErrorLineRule("", file, side, line, message)
+}
+
+// ErrorLineRule adds an error reported by the rule with the given ID to a
+// specific line in a file.
+func (r *Result) ErrorLineRule(rule string, file string, side string, line uint, message string) {
 	r.Comments = append(r.Comments, Comment{
 		File:     file,
 		Side:     side,
 		Line:     line,
 		Text:     message,
 		Severity: SeverityError,
+		Rule:     rule,
 	})
 }
@@ -0,0 +1,101 @@
+package manifest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+
+	"github.com/blakewilliams/manifest/pkg/gitattributes"
+)
+
+// binarySniffLength is how much of a file is checked for NUL bytes when
+// deciding if it's binary, matching git's heuristic.
+const binarySniffLength = 8000
+
+// NewScanDiff returns a diff where each of the given files in fsys is a new
+// file with every line added. It's used to run checkers against a whole tree
+// instead of a change. Binary files and files excluded using gitattributes
+// are skipped.
+func NewScanDiff(fsys fs.FS, paths []string, attributes *gitattributes.Attributes, opts ...DiffOption) (Diff, error) {
+	options := newDiffOptions(opts)
+
+	files := make([]File, 0, len(paths))
+	warnings := make([]string, 0)
+	var total int64
+
+	for _, name := range paths {
+		if attributes != nil && excludedFromScan(attributes.For(name)) {
+			continue
+		}
+
+		// Submodules and files deleted from the working tree are skipped
+		info, err := fs.Stat(fsys, name)
+		if errors.Is(err, fs.ErrNotExist) || (err == nil && !info.Mode().IsRegular()) {
+			continue
+		}
+
+		content, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return Diff{}, fmt.Errorf("could not read %s: %w", name, err)
+		}
+
+		if bytes.IndexByte(content[:min(len(content), binarySniffLength)], 0) >= 0 {
+			continue
+		}
+
+		total += int64(len(content))
+		if options.maxDiffBytes > 0 && total > options.maxDiffBytes {
+			warnings = append(warnings, fmt.Sprintf("files are larger than %d bytes, the remaining files were skipped", options.maxDiffBytes))
+			break
+		}
+
+		lines := linesOf(string(content))
+		f := File{
+			Operation: DiffOperationNew,
+			Name:      name,
+			Left:      make([]Line, 0),
+			Right:     lines,
+			Additions: len(lines),
+		}
+		if len(lines) > 0 {
+			f.Hunks = 1
+		}
+		annotateFile(&f)
+		truncateFile(&f, options)
+
+		files = append(files, f)
+	}
+
+	diff := newDiff(files, options)
+	if len(warnings) > 0 {
+		diff.Warnings = warnings
+	}
+
+	return diff, nil
+}
+
+// excludedFromScan returns true for files marked as binary, or that opt out of
+// scanning with the `-manifest` attribute.
+func excludedFromScan(attrs map[string]string) bool {
+	return attrs["diff"] == "false" || attrs["text"] == "false" || attrs["manifest"] == "false"
+}
+
+// linesOf splits the content of a file into lines numbered from 1, keeping
+// the trailing newline of each line like the lines of a diff.
+func linesOf(content string) []Line {
+	lines := make([]Line, 0, strings.Count(content, "\n")+1)
+
+	for n := uint(1); content != ""; n++ {
+		line, rest, found := strings.Cut(content, "\n")
+		if found {
+			line += "\n"
+		}
+
+		lines = append(lines, Line{LineNo: n, Content: line})
+		content = rest
+	}
+
+	return lines
+}
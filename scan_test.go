@@ -0,0 +1,52 @@
+package manifest
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/blakewilliams/manifest/pkg/gitattributes"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewScanDiff(t *testing.T) {
+	fsys := fstest.MapFS{
+		"app/jobs/greeter_job.rb": {Data: []byte("class GreeterJob\n  def perform(name)\n  end\nend")},
+		"empty.txt":               {Data: []byte("")},
+		"logo.png":                {Data: []byte("\x89PNG\x00\x01")},
+		"fixtures/big.json":       {Data: []byte("{}\n")},
+	}
+
+	attributes, err := gitattributes.Parse(strings.NewReader("fixtures/** -manifest\n"))
+	require.NoError(t, err)
+
+	diff, err := NewScanDiff(fsys, []string{"app/jobs/greeter_job.rb", "empty.txt", "fixtures/big.json", "logo.png", "missing.rb"}, attributes)
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"app/jobs/greeter_job.rb", "empty.txt"}, diff.NewFiles)
+
+	file := diff.Files["app/jobs/greeter_job.rb"]
+	require.Equal(t, DiffOperationNew, file.Operation)
+	require.Equal(t, "Ruby", file.Language)
+	require.Empty(t, file.Left)
+	require.Equal(t, []Line{
+		{LineNo: 1, Content: "class GreeterJob\n"},
+		{LineNo: 2, Content: "  def perform(name)\n"},
+		{LineNo: 3, Content: "  end\n"},
+		{LineNo: 4, Content: "end"},
+	}, file.Right)
+	require.Equal(t, 4, diff.Stats.Additions)
+}
+
+func TestNewScanDiff_MaxDiffBytes(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.txt": {Data: []byte("a\n")},
+		"b.txt": {Data: []byte("b\n")},
+	}
+
+	diff, err := NewScanDiff(fsys, []string{"a.txt", "b.txt"}, nil, WithMaxDiffBytes(3))
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"a.txt"}, diff.NewFiles)
+	require.Len(t, diff.Warnings, 1)
+}
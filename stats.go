@@ -0,0 +1,91 @@
+package manifest
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// DiffStats summarizes the size of a diff.
+type DiffStats struct {
+	// Files is the number of files in the diff.
+	Files int `json:"files"`
+	// Additions is the number of added lines, excluding context lines.
+	Additions int `json:"additions"`
+	// Deletions is the number of deleted lines, excluding context lines.
+	Deletions int `json:"deletions"`
+	// Hunks is the number of hunks across all files.
+	Hunks int `json:"hunks"`
+	// Churn is the number of lines touched by the diff. Modified lines count
+	// twice since they're both deleted and added.
+	Churn int `json:"churn"`
+	// ByDirectory is the number of files changed in each directory.
+	ByDirectory map[string]int `json:"byDirectory"`
+	// ByLanguage is the number of files changed for each language. Files with
+	// an unknown language are counted as "Other".
+	ByLanguage map[string]int `json:"byLanguage"`
+}
+
+// statsForFiles computes the stats of the given files.
+func statsForFiles(files map[string]File) DiffStats {
+	stats := DiffStats{
+		Files:       len(files),
+		ByDirectory: make(map[string]int),
+		ByLanguage:  make(map[string]int),
+	}
+
+	for _, file := range files {
+		stats.Additions += file.Additions
+		stats.Deletions += file.Deletions
+		stats.Hunks += file.Hunks
+
+		stats.ByDirectory[path.Dir(file.path())]++
+
+		language := file.Language
+		if language == "" {
+			language = "Other"
+		}
+		stats.ByLanguage[language]++
+	}
+
+	stats.Churn = stats.Additions + stats.Deletions
+
+	return stats
+}
+
+// countRuns returns the number of runs of consecutive line numbers in the
+// given lines. It's used to estimate the hunks of files that weren't parsed
+// from a single diff.
+func countRuns(lines []Line) int {
+	runs := 0
+	for n, line := range lines {
+		if n == 0 || lines[n-1].LineNo+1 != line.LineNo {
+			runs++
+		}
+	}
+
+	return runs
+}
+
+// String returns a one-line summary of the stats, similar to `git diff --stat`.
+func (s DiffStats) String() string {
+	var summary strings.Builder
+
+	summary.WriteString(pluralize(s.Files, "file changed", "files changed"))
+	summary.WriteString(", ")
+	summary.WriteString(pluralize(s.Additions, "insertion(+)", "insertions(+)"))
+	summary.WriteString(", ")
+	summary.WriteString(pluralize(s.Deletions, "deletion(-)", "deletions(-)"))
+	summary.WriteString(" in ")
+	summary.WriteString(pluralize(s.Hunks, "hunk", "hunks"))
+
+	return summary.String()
+}
+
+func pluralize(count int, singular string, plural string) string {
+	if count == 1 {
+		return fmt.Sprintf("%d %s", count, singular)
+	}
+
+	return fmt.Sprintf("%d %s", count, plural)
+}
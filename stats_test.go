@@ -0,0 +1,52 @@
+package manifest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var statsDiff = `
+diff --git a/app/jobs/greeter_job.rb b/app/jobs/greeter_job.rb
+index abc1234..def5678 100644
+--- a/app/jobs/greeter_job.rb
++++ b/app/jobs/greeter_job.rb
+@@ -1,4 +1,5 @@
+ class GreeterJob < ApplicationJob
+-  def perform
++  def perform(name)
++    puts name
+   end
+ end
+@@ -10,2 +11,1 @@ end
+ # trailing
+-# removed
+diff --git a/README.md b/README.md
+new file mode 100644
+index 0000000..e69de29
+--- /dev/null
++++ b/README.md
+@@ -0,0 +1,1 @@
++# The truth is out there`
+
+func TestNewDiff_Stats(t *testing.T) {
+	diff, err := NewDiff(strings.NewReader(statsDiff))
+	require.NoError(t, err)
+
+	job := diff.Files["app/jobs/greeter_job.rb"]
+	require.Equal(t, 2, job.Additions)
+	require.Equal(t, 2, job.Deletions)
+	require.Equal(t, 2, job.Hunks)
+
+	stats := diff.Stats
+	require.Equal(t, 2, stats.Files)
+	require.Equal(t, 3, stats.Additions)
+	require.Equal(t, 2, stats.Deletions)
+	require.Equal(t, 3, stats.Hunks)
+	require.Equal(t, 5, stats.Churn)
+	require.Equal(t, map[string]int{"app/jobs": 1, ".": 1}, stats.ByDirectory)
+	require.Equal(t, map[string]int{"Ruby": 1, "Markdown": 1}, stats.ByLanguage)
+
+	require.Equal(t, "2 files changed, 3 insertions(+), 2 deletions(-) in 3 hunks", stats.String())
+}
@@ -0,0 +1,261 @@
+package manifest
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// IgnoreDirective suppresses the comments of the checkers listed after it, on
+// the line it's written on and on the line after it, e.g.
+// `# manifest:ignore no_todos` or `// manifest:ignore no_todos, naming`.
+const IgnoreDirective = "manifest:ignore"
+
+// BaselinePath is where the baseline is stored, relative to the root of the
+// repository.
+const BaselinePath = ".manifest/baseline.json"
+
+var directiveRegexp = regexp.MustCompile(regexp.QuoteMeta(IgnoreDirective) + `\s+([\w.-]+(?:\s*,\s*[\w.-]+)*)`)
+
+// directiveComments are the comment delimiters used to write the
+// IgnoreDirective, by language.
+var directiveComments = map[string][2]string{
+	"C":               {"//", ""},
+	"C++":             {"//", ""},
+	"C#":              {"//", ""},
+	"CSS":             {"/*", " */"},
+	"SCSS":            {"//", ""},
+	"HTML+ERB":        {"<%#", " %>"},
+	"Elixir":          {"#", ""},
+	"Go":              {"//", ""},
+	"Go Module":       {"//", ""},
+	"HTML":            {"<!--", " -->"},
+	"Java":            {"//", ""},
+	"JavaScript":      {"//", ""},
+	"Kotlin":          {"//", ""},
+	"Lua":             {"--", ""},
+	"Markdown":        {"<!--", " -->"},
+	"PHP":             {"//", ""},
+	"Perl":            {"#", ""},
+	"Protocol Buffer": {"//", ""},
+	"Python":          {"#", ""},
+	"Ruby":            {"#", ""},
+	"Rust":            {"//", ""},
+	"Scala":           {"//", ""},
+	"Shell":           {"#", ""},
+	"SQL":             {"--", ""},
+	"Swift":           {"//", ""},
+	"TOML":            {"#", ""},
+	"TypeScript":      {"//", ""},
+	"TSX":             {"//", ""},
+	"YAML":            {"#", ""},
+	"Dockerfile":      {"#", ""},
+	"Makefile":        {"#", ""},
+}
+
+// Directive returns the line suppressing the comments of checker in a file of
+// the given language, or false if the language doesn't support comments.
+func Directive(language string, checker string) (string, bool) {
+	delimiters, ok := directiveComments[language]
+	if !ok {
+		return "", false
+	}
+
+	return fmt.Sprintf("%s %s %s%s", delimiters[0], IgnoreDirective, checker, delimiters[1]), true
+}
+
+// directiveIgnores returns true if the line contains an IgnoreDirective for
+// the checker.
+func directiveIgnores(line string, checker string) bool {
+	for _, match := range directiveRegexp.FindAllStringSubmatch(line, -1) {
+		for _, name := range strings.Split(match[1], ",") {
+			if strings.TrimSpace(name) == checker {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// BaselineEntry is a known comment that shouldn't be reported. Entries don't
+// include line numbers so they keep matching as the file changes.
+type BaselineEntry struct {
+	Checker string `json:"checker"`
+	File    string `json:"file,omitempty"`
+	Rule    string `json:"rule,omitempty"`
+	Text    string `json:"text"`
+}
+
+// Baseline is the list of known comments that shouldn't be reported, like the
+// existing findings of a newly added checker.
+type Baseline struct {
+	Entries []BaselineEntry `json:"entries"`
+}
+
+// LoadBaseline reads the baseline at path, returning an empty baseline if it
+// doesn't exist.
+func LoadBaseline(path string) (*Baseline, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &Baseline{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read baseline: %w", err)
+	}
+
+	baseline, err := ParseBaseline(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return baseline, nil
+}
+
+// ParseBaseline parses the content of a baseline file, e.g. one read from
+// another revision of the repository.
+func ParseBaseline(data []byte) (*Baseline, error) {
+	baseline := &Baseline{}
+	if err := json.Unmarshal(data, baseline); err != nil {
+		return nil, fmt.Errorf("could not parse baseline: %w", err)
+	}
+
+	return baseline, nil
+}
+
+func baselineEntry(checker string, comment Comment) BaselineEntry {
+	return BaselineEntry{Checker: checker, File: comment.File, Rule: comment.Rule, Text: comment.Text}
+}
+
+// Contains returns true if the comment of the checker is in the baseline.
+func (b *Baseline) Contains(checker string, comment Comment) bool {
+	entry := baselineEntry(checker, comment)
+	for _, existing := range b.Entries {
+		if existing == entry {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Add adds the comment of the checker to the baseline.
+func (b *Baseline) Add(checker string, comment Comment) {
+	if b.Contains(checker, comment) {
+		return
+	}
+
+	b.Entries = append(b.Entries, baselineEntry(checker, comment))
+}
+
+// Write writes the baseline to path, sorted so that it diffs well.
+func (b *Baseline) Write(path string) error {
+	sort.Slice(b.Entries, func(i, j int) bool {
+		a, c := b.Entries[i], b.Entries[j]
+		if a.Checker != c.Checker {
+			return a.Checker < c.Checker
+		}
+		if a.File != c.File {
+			return a.File < c.File
+		}
+		if a.Rule != c.Rule {
+			return a.Rule < c.Rule
+		}
+		return a.Text < c.Text
+	})
+
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not marshal baseline: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("could not create baseline directory: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("could not write baseline: %w", err)
+	}
+
+	return nil
+}
+
+// Suppressions removes the comments suppressed using an IgnoreDirective in
+// the commented file, or listed in the baseline.
+type Suppressions struct {
+	// Root is the directory the commented files are read from to find
+	// directives. Directives are ignored when it's empty.
+	Root     string
+	Baseline *Baseline
+}
+
+// Filter returns the comments of the checker that aren't suppressed.
+func (s *Suppressions) Filter(checker string, comments []Comment) []Comment {
+	// Each commented file is only read once, however many comments it has
+	files := make(map[string][]string)
+	kept := make([]Comment, 0, len(comments))
+	for _, comment := range comments {
+		if !s.suppressed(checker, comment, files) {
+			kept = append(kept, comment)
+		}
+	}
+
+	return kept
+}
+
+// Suppressed returns true if the comment of the checker is suppressed.
+func (s *Suppressions) Suppressed(checker string, comment Comment) bool {
+	return s.suppressed(checker, comment, make(map[string][]string))
+}
+
+// suppressed is Suppressed, reading the lines of the commented file from files
+// and adding them to it when they weren't read yet.
+func (s *Suppressions) suppressed(checker string, comment Comment, files map[string][]string) bool {
+	if s.Baseline != nil && s.Baseline.Contains(checker, comment) {
+		return true
+	}
+
+	// Deleted lines can't have directives
+	if s.Root == "" || comment.File == "" || comment.Line == 0 || comment.Side == "LEFT" {
+		return false
+	}
+
+	lines, ok := files[comment.File]
+	if !ok {
+		lines = readLines(filepath.Join(s.Root, filepath.FromSlash(comment.File)))
+		files[comment.File] = lines
+	}
+	for _, lineNo := range []uint{comment.Line, comment.Line - 1} {
+		if lineNo >= 1 && int(lineNo) <= len(lines) && directiveIgnores(lines[lineNo-1], checker) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// readLines returns the lines of the file at path, or none if it can't be
+// read.
+func readLines(path string) []string {
+	lines := make([]string, 0)
+
+	f, err := os.Open(path)
+	if err != nil {
+		return lines
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+
+	return lines
+}
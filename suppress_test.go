@@ -0,0 +1,103 @@
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDirective(t *testing.T) {
+	directive, ok := Directive("Ruby", "no_todos")
+	require.True(t, ok)
+	require.Equal(t, "# manifest:ignore no_todos", directive)
+
+	directive, ok = Directive("HTML", "no_todos")
+	require.True(t, ok)
+	require.Equal(t, "<!-- manifest:ignore no_todos -->", directive)
+
+	_, ok = Directive("JSON", "no_todos")
+	require.False(t, ok)
+
+	require.True(t, directiveIgnores("x = 1 // manifest:ignore naming, no_todos", "no_todos"))
+	require.True(t, directiveIgnores("<!-- manifest:ignore no_todos -->", "no_todos"))
+	require.False(t, directiveIgnores("# manifest:ignore no_todos_v2", "no_todos"))
+	require.False(t, directiveIgnores("# manifest:ignore", "no_todos"))
+}
+
+func TestBaseline(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".manifest", "baseline.json")
+
+	baseline, err := LoadBaseline(path)
+	require.NoError(t, err)
+	require.Empty(t, baseline.Entries)
+
+	comment := Comment{File: "app.rb", Line: 3, Text: "TODO found", Rule: "todo"}
+	baseline.Add("no_todos", comment)
+	baseline.Add("no_todos", comment)
+	baseline.Add("naming", Comment{Text: "Bad name"})
+	require.NoError(t, baseline.Write(path))
+
+	loaded, err := LoadBaseline(path)
+	require.NoError(t, err)
+	require.Equal(t, []BaselineEntry{
+		{Checker: "naming", Text: "Bad name"},
+		{Checker: "no_todos", File: "app.rb", Rule: "todo", Text: "TODO found"},
+	}, loaded.Entries)
+
+	// Line numbers aren't part of the baseline
+	comment.Line = 10
+	require.True(t, loaded.Contains("no_todos", comment))
+	require.False(t, loaded.Contains("other", comment))
+}
+
+func TestSuppressions(t *testing.T) {
+	root := t.TempDir()
+	source := strings.Join([]string{
+		"# manifest:ignore no_todos",
+		"# TODO first",
+		"# TODO second",
+		"# TODO third # manifest:ignore no_todos",
+	}, "\n")
+	require.NoError(t, os.WriteFile(filepath.Join(root, "app.rb"), []byte(source), 0o644))
+
+	suppressions := &Suppressions{
+		Root:     root,
+		Baseline: &Baseline{Entries: []BaselineEntry{{Checker: "naming", Text: "Bad name"}}},
+	}
+
+	comments := []Comment{
+		{File: "app.rb", Line: 2, Side: "RIGHT", Text: "first"},
+		{File: "app.rb", Line: 3, Side: "RIGHT", Text: "second"},
+		{File: "app.rb", Line: 4, Side: "RIGHT", Text: "third"},
+		{File: "app.rb", Line: 2, Side: "LEFT", Text: "deleted"},
+		{File: "missing.rb", Line: 1, Text: "missing"},
+	}
+	kept := suppressions.Filter("no_todos", comments)
+
+	texts := make([]string, len(kept))
+	for n, comment := range kept {
+		texts[n] = comment.Text
+	}
+	require.Equal(t, []string{"second", "deleted", "missing"}, texts)
+
+	require.Len(t, suppressions.Filter("other", comments), len(comments))
+	require.Empty(t, suppressions.Filter("naming", []Comment{{Text: "Bad name"}}))
+}
+
+func TestPerform_Suppressions(t *testing.T) {
+	config := &Configuration{
+		Concurrency:  1,
+		Formatter:    &recordingFormatter{},
+		Suppressions: &Suppressions{Baseline: &Baseline{Entries: []BaselineEntry{{Checker: "error", Text: "nope"}}}},
+		Checkers: map[string]Checker{
+			"error": {Command: `echo '{"comments":[{"text":"nope","severity":"Error"}]}'`},
+		},
+	}
+
+	check, err := NewCheck(config, strings.NewReader(newFile))
+	require.NoError(t, err)
+	require.NoError(t, check.Perform())
+}